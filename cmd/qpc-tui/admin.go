@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/charmbracelet/log"
+
+	"qpc-tui/internal/scraper"
+)
+
+// healthz is the body of /healthz.
+type healthz struct {
+	scraper.HealthReport
+	// BreakerOpen is whether the requests to the site are paused because it's down
+	BreakerOpen bool `json:"breaker_open"`
+}
+
+// serveAdmin serves the admin endpoints on addr until ctx is done. GET /healthz
+// answers with the health of the scraper and whether the breaker is open, 200 while
+// the selectors match the site and 503 once the required ones stop matching.
+func serveAdmin(ctx context.Context, addr string, health *scraper.Health, breaker *scraper.Breaker) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		report := health.Report()
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status != scraper.HealthOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(healthz{HealthReport: report, BreakerOpen: breaker.Open()}); err != nil {
+			log.Error("Could not write the health report", "error", err)
+		}
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Info("Starting admin server", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Error("Could not start admin server", "error", err)
+	}
+}
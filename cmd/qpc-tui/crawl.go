@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"qpc-tui/internal/archive"
+	"qpc-tui/internal/crawler"
+	"qpc-tui/internal/scraper"
+)
+
+// crawl backfills the archive with the articles of the site's older pages.
+func crawl(args []string) error {
+	fs := flag.NewFlagSet("crawl", flag.ExitOnError)
+	fromPage := fs.Int("from-page", 1, "first page of the listing to crawl")
+	toPage := fs.Int("to-page", 500, "last page of the listing to crawl")
+	delay := fs.Duration("delay", 2*time.Second, "time to wait between pages")
+	path := fs.String("archive", defaultArchivePath, "file where every scraped article is stored")
+	selectorsPath := fs.String("selectors", "", "YAML file with the CSS selectors of the site (default: the built-in ones)")
+	statePath := fs.String("state", "", "file where the progress is saved to resume the crawl (default: the archive path plus .crawl)")
+	fs.Parse(args)
+
+	if *statePath == "" {
+		*statePath = *path + ".crawl"
+	}
+
+	selectors, err := loadSelectors(*selectorsPath)
+	if err != nil {
+		log.Error("Could not load the selectors", "path", *selectorsPath, "error", err)
+		return err
+	}
+
+	store, err := archive.Open(*path)
+	if err != nil {
+		log.Error("Could not open the archive", "path", *path, "error", err)
+		return err
+	}
+	defer store.Close()
+
+	// Stop between pages on Ctrl+C, the progress is saved so the crawl can be resumed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Info("Starting crawl", "from", *fromPage, "to", *toPage, "delay", *delay)
+	err = crawler.Run(ctx, scraper.NewQPC(scraper.WithSelectors(selectors)), store, crawler.Options{
+		FromPage:  *fromPage,
+		ToPage:    *toPage,
+		Delay:     *delay,
+		StatePath: *statePath,
+	}, func(p crawler.Progress) {
+		if p.Retried {
+			log.Info("Retried failed articles", "loaded", p.Articles, "failed", p.Failed, "archived", p.Archived)
+			return
+		}
+		log.Info("Crawled page", "page", p.Page, "of", p.ToPage, "articles", p.Articles, "failed", p.Failed, "archived", p.Archived)
+	})
+	if errors.Is(err, context.Canceled) {
+		log.Info("Crawl interrupted, run the same command again to resume it")
+		return nil
+	}
+	if err != nil {
+		log.Error("Crawl failed, run the same command again to resume it", "error", err)
+		return err
+	}
+	log.Info("Crawl finished", "archived", store.Len())
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"net"
 	"os"
 	"os/signal"
@@ -15,29 +16,152 @@ import (
 	"github.com/charmbracelet/wish/activeterm"
 	"github.com/charmbracelet/wish/bubbletea"
 	"github.com/charmbracelet/wish/logging"
+	"github.com/muesli/termenv"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"qpc-tui/internal/app"
+	"qpc-tui/internal/archive"
+	"qpc-tui/internal/scraper"
+	"qpc-tui/internal/syncer"
 )
 
 const (
 	host = "0.0.0.0"
 	port = "22"
+
+	defaultArchivePath = "data/archive.jsonl"
+	defaultSeenPath    = "data/seen.txt"
+)
+
+var (
+	cacheTTL      = flag.Duration("cache-ttl", 5*time.Minute, "how long scraped pages and articles are shared between sessions")
+	archivePath   = flag.String("archive", defaultArchivePath, "file where every scraped article is stored")
+	seenPath      = flag.String("seen", defaultSeenPath, "file where the links already synced are stored")
+	syncInterval  = flag.Duration("sync-interval", 5*time.Minute, "how often to check the site for new articles")
+	selectorsPath = flag.String("selectors", "", "YAML file with the CSS selectors of the site, reloaded on SIGHUP (default: the built-in ones)")
+	adminAddr     = flag.String("admin-addr", "127.0.0.1:8080", "address of the admin HTTP server with the /healthz endpoint, empty to disable it")
+
+	parallelism     = flag.Int("parallelism", scraper.DefaultLimits.Parallelism, "maximum concurrent requests of a single scrape")
+	randomDelay     = flag.Duration("random-delay", scraper.DefaultLimits.RandomDelay, "maximum random delay added after each request of a scrape")
+	maxRequests     = flag.Int("max-requests", 4, "maximum concurrent requests to the site across every session")
+	requestInterval = flag.Duration("request-interval", 200*time.Millisecond, "minimum time between the start of two requests to the site")
+	retries         = flag.Int("retries", scraper.DefaultRetryPolicy.Attempts-1, "how many times a failed request to the site is retried")
+	retryDelay      = flag.Duration("retry-delay", scraper.DefaultRetryPolicy.BaseDelay, "wait before the first retry, it doubles with every retry")
+	requestTimeout  = flag.Duration("request-timeout", scraper.DefaultRetryPolicy.Timeout, "how long each attempt of a request to the site may take, 0 for no limit")
+	breakerFailures = flag.Int("breaker-failures", scraper.DefaultBreakerPolicy.Failures, "failed requests in a row after which requests to the site are paused, 0 to never pause them")
+	breakerCooldown = flag.Duration("breaker-cooldown", scraper.DefaultBreakerPolicy.Cooldown, "how long requests to the site are paused before trying it again")
+	probeInterval   = flag.Duration("probe-interval", scraper.DefaultMonitorPolicy.Interval, "how often to check if the site responds")
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "crawl" {
+		if err := crawl(os.Args[2:]); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	flag.Parse()
+
+	// Every scraped article is persisted, so the history survives restarts
+	store, err := archive.Open(*archivePath)
+	if err != nil {
+		log.Fatal("Could not open the archive", "path", *archivePath, "error", err)
+	}
+	defer store.Close()
+
+	selectors, err := loadSelectors(*selectorsPath)
+	if err != nil {
+		log.Fatal("Could not load the selectors", "path", *selectorsPath, "error", err)
+	}
+
+	// The news source every session reads from, cached so sessions share what the others already scraped
+	qpc := scraper.NewQPC(scraper.WithSelectors(selectors), scraper.WithLimits(scraper.Limits{
+		Parallelism: *parallelism,
+		RandomDelay: *randomDelay,
+		Budget:      scraper.NewBudget(*maxRequests, *requestInterval),
+	}), scraper.WithRetry(scraper.RetryPolicy{
+		Attempts:  *retries + 1,
+		BaseDelay: *retryDelay,
+		MaxDelay:  scraper.DefaultRetryPolicy.MaxDelay,
+		Timeout:   *requestTimeout,
+	}), scraper.WithBreaker(scraper.BreakerPolicy{
+		Failures: *breakerFailures,
+		Cooldown: *breakerCooldown,
+	}))
+	source := scraper.NewCache(store.Wrap(qpc), *cacheTTL)
+
+	// Keep up with the new articles in the background, background work stops when the server does
+	seen, err := syncer.OpenSeen(*seenPath)
+	if err != nil {
+		log.Fatal("Could not open the seen links", "path", *seenPath, "error", err)
+	}
+	defer seen.Close()
+
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Open sessions, the background work below tells them what it finds
+	programs := newProgramRegistry()
+
+	// Sessions warn their users when the site can't be read anymore, and so does the admin server
+	qpc.Health().OnChange(func(report scraper.HealthReport) {
+		if report.Status == scraper.HealthDegraded {
+			log.Error("The selectors stopped matching the site", "failing", report.Failing)
+		} else {
+			log.Info("The selectors match the site again")
+		}
+		programs.broadcast(app.SourceHealthMsg{Failing: report.Failing})
+	})
+	if *adminAddr != "" {
+		go serveAdmin(bgCtx, *adminAddr, qpc.Health(), qpc.Breaker())
+	}
+
+	// Sessions show if the site responds and retry what failed once it's back. A site
+	// that responds again doesn't need to wait for the breaker's cooldown
+	monitor := scraper.NewMonitor(qpc.BaseURL(), scraper.MonitorPolicy{
+		Interval:    *probeInterval,
+		Timeout:     scraper.DefaultMonitorPolicy.Timeout,
+		SlowLatency: scraper.DefaultMonitorPolicy.SlowLatency,
+	})
+	monitor.OnProbe(func(probe scraper.Probe) {
+		// A slow or failing site keeps the breaker as it is, only a healthy one closes it
+		if probe.Status == scraper.ConnectivityOnline {
+			qpc.Breaker().Reset()
+		}
+		programs.broadcast(app.ConnectivityMsg{Probe: probe})
+	})
+	go monitor.Run(bgCtx)
+
+	// When the syncer finds new articles, every open session is told so it can offer to reload
+	go syncer.New(source, seen).Run(bgCtx, *syncInterval, func(articles []scraper.Article) {
+		source.InvalidatePages()
+		programs.broadcast(app.NewEntriesMsg{Count: len(articles)})
+	})
+
+	// Report selectors that don't match the site anymore, and pick up fixed ones without a restart
+	go checkSelectors(bgCtx, qpc)
+	go reloadSelectorsOnHangup(bgCtx, qpc, source, *selectorsPath)
+
 	// Initialize the server
 	s, err := wish.NewServer(
 		// Set the address to the host and port, using net.JoinHostPort to combine them
 		wish.WithAddress(net.JoinHostPort(host, port)),
 		wish.WithHostKeyPath(".ssh/id_ed25519"),
 		wish.WithMiddleware(
-			// Initialize the Bubble Tea middleware with a custom function that initializes the Bubble Tea model and options
-			bubbletea.Middleware(func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
-				m, opts := app.InitialModel(s)
-				return m, opts
-			}),
+			// Initialize the Bubble Tea middleware with a custom function that initializes the program,
+			// we build the program ourselves so it can be registered while the session is open
+			bubbletea.MiddlewareWithProgramHandler(func(s ssh.Session) *tea.Program {
+				m, opts := app.InitialModel(s, source, qpc.Health(), monitor)
+				p := tea.NewProgram(m, append(opts, bubbletea.MakeOptions(s)...)...)
+				programs.add(p)
+				go func() {
+					<-s.Context().Done()
+					programs.remove(p)
+				}()
+				return p
+			}, termenv.Ascii),
 			activeterm.Middleware(),
 			logging.Middleware(),
 		),
@@ -68,4 +192,4 @@ func main() {
 	if err := s.Shutdown(ctx); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
 		log.Error("Could not stop server", "error", err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// programRegistry keeps track of the bubbletea programs of the open sessions,
+// so the server can push messages to all of them.
+type programRegistry struct {
+	mu       sync.Mutex
+	programs map[*tea.Program]struct{}
+}
+
+func newProgramRegistry() *programRegistry {
+	return &programRegistry{programs: make(map[*tea.Program]struct{})}
+}
+
+func (r *programRegistry) add(p *tea.Program) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.programs[p] = struct{}{}
+}
+
+func (r *programRegistry) remove(p *tea.Program) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.programs, p)
+}
+
+// broadcast sends the message to every open session.
+func (r *programRegistry) broadcast(msg tea.Msg) {
+	r.mu.Lock()
+	programs := make([]*tea.Program, 0, len(r.programs))
+	for p := range r.programs {
+		programs = append(programs, p)
+	}
+	r.mu.Unlock()
+
+	// Send blocks until the program reads the message, don't let a slow session hold the others
+	for _, p := range programs {
+		go p.Send(msg)
+	}
+}
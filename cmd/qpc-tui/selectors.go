@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/charmbracelet/log"
+
+	"qpc-tui/internal/scraper"
+)
+
+// loadSelectors returns the selectors of the file at path, the built-in ones when path is empty.
+func loadSelectors(path string) (*scraper.Selectors, error) {
+	if path == "" {
+		return scraper.DefaultSelectors, nil
+	}
+	return scraper.LoadSelectors(path)
+}
+
+// reloadSelectorsOnHangup loads the selectors file again every time the server gets a
+// SIGHUP, until ctx is done. A file that doesn't load is logged and the selectors in
+// use are kept.
+func reloadSelectorsOnHangup(ctx context.Context, qpc *scraper.QPC, cache *scraper.Cache, path string) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+		}
+
+		selectors, err := loadSelectors(path)
+		if err != nil {
+			log.Error("Could not reload the selectors, keeping the ones in use", "path", path, "error", err)
+			continue
+		}
+		qpc.SetSelectors(selectors)
+		// Pages scraped with the old selectors may be missing articles
+		cache.InvalidatePages()
+		log.Info("Reloaded the selectors", "path", path)
+		checkSelectors(ctx, qpc)
+	}
+}
+
+// checkSelectors logs the selectors that match nothing on the site.
+func checkSelectors(ctx context.Context, qpc *scraper.QPC) {
+	checks, err := qpc.CheckSelectors(ctx)
+	if err != nil {
+		log.Warn("Could not check the selectors against the site", "error", err)
+		return
+	}
+	for _, check := range checks {
+		if check.Matches > 0 {
+			continue
+		}
+		if check.Optional {
+			log.Debug("Optional selector matched nothing", "name", check.Name, "selector", check.Selector, "url", check.URL)
+			continue
+		}
+		log.Warn("Selector matched nothing, the site may have changed", "name", check.Name, "selector", check.Selector, "url", check.URL)
+	}
+}
@@ -25,18 +25,10 @@ func NewCustomDelegate(renderer *lipgloss.Renderer, model Model) list.ItemDelega
 	return &customDelegate{renderer: renderer, model: model}
 }
 
-func (d customDelegate) Height() int                               { return 2 }
+func (d customDelegate) Height() int                               { return 3 }
 func (d customDelegate) Spacing() int                              { return 1 }
 func (d customDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
 
-type Category int
-
-const (
-	Policiales Category = 8
-	Sociedad Category = 48
-	Automotores Category = 75
-)
-
 
 func (d customDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
 	i, ok := listItem.(item)
@@ -46,11 +38,15 @@ func (d customDelegate) Render(w io.Writer, m list.Model, index int, listItem li
 
 	indexStr := fmt.Sprintf("%d. ", index+1)
 	titleStr := i.title
-	var subtitle string
+	var subtitle, summary string
 	if d.model.Entries != nil {
 		for _, entry := range d.model.Entries {
-			if entry.Title == i.title && entry.Date == i.desc {
-				subtitle = fmt.Sprintf("%s | %s", entry.Category, entry.Date)
+			if entry.ID == i.id {
+				subtitle = fmt.Sprintf("%s | %s", entry.Category, entry.Date.Format(dateLayout))
+				if entry.Author != "" {
+					subtitle += " | " + entry.Author
+				}
+				summary = entry.Lead
 				break
 			}
 		}
@@ -74,9 +70,18 @@ func (d customDelegate) Render(w io.Writer, m list.Model, index int, listItem li
 		Foreground(lipgloss.Color("8")).
 		MarginLeft(8)
 
+	// The summary is cut to a single line so every item has the same height
+	summaryStyle := d.renderer.NewStyle().
+		Foreground(lipgloss.Color("7")).
+		MarginLeft(8).
+		MaxWidth(m.Width())
+
 	fmt.Fprint(w, indexStyle.Render(indexStr))
 	fmt.Fprint(w, titleStyle.Render(titleStr))
 	if subtitle != "" {
 		fmt.Fprint(w, "\n"+subtitleStyle.Render(subtitle))
 	}
+	if summary != "" {
+		fmt.Fprint(w, "\n"+summaryStyle.Render(summary))
+	}
 }
\ No newline at end of file
@@ -1,6 +1,9 @@
 package app
 
 import (
+	"context"
+	"time"
+
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -23,14 +26,24 @@ type Model struct {
 	TxtStyle  lipgloss.Style
 	QuitStyle lipgloss.Style
 
-	Status      int
+	Source      scraper.Source
+	Connectivity scraper.Probe // Last probe of the site, announced by the server
 	CurrentPage int
 	Entries     []scraper.Article
+	Failures    []scraper.ArticleError // Articles of the current page that couldn't be loaded
 	CanContinue bool
 	CanGoBack   bool
-	Err         error
-
-	CurrentCategory int // 0: all (0), 1: policiales (8), 2: sociedad (48), 3: automotores (75)
+	FetchedAt   time.Time // When the page on screen was scraped
+	Stale       bool      // The page on screen is an older copy, it's being fetched again
+	FetchErr    error     // Why the last fetch failed, the entries on screen are kept
+	Banner      *ErrorBanner // Recoverable error shown above the list
+	NewEntries  int // Entries published since the list was loaded, announced by the server
+	// Selectors that stopped matching the site, the entries may be missing or incomplete
+	FailingSelectors []string
+
+	Categories      []scraper.Category // Discovered from the site, loaded on Init
+	CurrentCategory int                // Selected tab, 0 is every category and the rest index Categories from 1
+	Feeds           map[int]Feed       // Last page loaded in each tab, by category id
 	SelectedEntry		*scraper.Article
 
 	Keys         ui.KeyMap
@@ -41,14 +54,53 @@ type Model struct {
 	IsFirstFetch bool
 	Quitting     bool
 	FetchCmd     tea.Cmd
+	FetchingPage int
+	FetchingCategory int
+	FetchDone    int // Articles of the fetch in flight scraped or failed so far, out of FetchTotal
+	FetchTotal   int
+	Streamed     int // Articles of the fetch in flight already shown in the list
+	Retrying     bool // The fetch in flight only retries the articles that failed
 	Spinner      spinner.Model
 	List         list.Model
 	Viewport     viewport.Model
 
 	renderer *lipgloss.Renderer
+
+	// ctx lives as long as the SSH session, cancelFetch aborts the fetch in flight
+	// and cancelPrefetch the pages being loaded in the background
+	ctx            context.Context
+	cancelFetch    context.CancelFunc
+	cancelPrefetch context.CancelFunc
+
+	// revalidateDelay is the wait before fetching again a stale page, revalidateSeq
+	// identifies the last revalidation scheduled
+	revalidateDelay time.Duration
+	revalidateSeq   int
+}
+
+// ErrorBanner is a recoverable error, shown above the list until the user retries
+// what failed or dismisses it. The session and what's on screen stay as they were.
+type ErrorBanner struct {
+	Message string
+	Err     error
+	// retry repeats what failed, nil if it can't be retried
+	retry func(m *Model) tea.Cmd
+	// fetch errors go away once the entries are fetched
+	fetch bool
+}
+
+// Feed is the state of a tab, each category pages through the listing of its own section.
+type Feed struct {
+	Page        int
+	Entries     []scraper.Article
+	Failures    []scraper.ArticleError
+	CanContinue bool
+	CanGoBack   bool
+	FetchedAt   time.Time
+	Stale       bool
 }
 
-func InitialModel(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+func InitialModel(s ssh.Session, source scraper.Source, health *scraper.Health, monitor *scraper.Monitor) (tea.Model, []tea.ProgramOption) {
 	// The pty is the pseudo terminal that is created when the program starts,
 	// it is used to get the size of the terminal.
 	pty, _, _ := s.Pty()
@@ -78,7 +130,12 @@ func InitialModel(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 		TxtStyle:  txtStyle,
 		QuitStyle: quitStyle,
 
+		Source:           source,
+		FailingSelectors: health.Report().Failing,
+		Connectivity:     monitor.Last(),
+
 		CurrentCategory: 0,
+		Feeds:           make(map[int]Feed),
 		SelectedEntry: nil,
 
 		CurrentPage: 0,
@@ -92,6 +149,8 @@ func InitialModel(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 		Viewport: viewport.New(width, height-8),
 
 		renderer: renderer,
+
+		ctx: s.Context(),
 	}
 
 	// To make the list work correctly with our custom renderer we need to use a custom
@@ -112,5 +171,8 @@ func InitialModel(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 
 	m.List = l
 
+	// Start fetching the first page right away, Init will run the command
+	m.startFetch(m.CurrentPage)
+
 	return m, []tea.ProgramOption{tea.WithAltScreen()}
 }
\ No newline at end of file
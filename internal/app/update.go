@@ -1,7 +1,7 @@
 package app
 
 import (
-	"net/http"
+	"context"
 	"time"
 	"fmt"
 	"sort"
@@ -9,49 +9,318 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/log"
 
 	"qpc-tui/internal/scraper"
 )
 
-const url = "https://quepensaschacabuco.com/"
+// errMsg is a failed fetch of a page of the category's listing.
+type errMsg struct {
+	err      error
+	page     int
+	category int
+}
 
-type statusMsg int
+func (e errMsg) Error() string { return e.err.Error() }
 
-type errMsg struct{ err error }
+// NewEntriesMsg is sent by the server to every open session when new articles are published.
+type NewEntriesMsg struct {
+	Count int
+}
 
-func (e errMsg) Error() string { return e.err.Error() }
+// ConnectivityMsg is sent by the server to every open session each time it probes the site.
+type ConnectivityMsg struct {
+	Probe scraper.Probe
+}
 
-func checkServer() tea.Msg {
-	c := &http.Client{Timeout: 10 * time.Second}
-	res, err := c.Get(url)
+// SourceHealthMsg is sent by the server to every open session when the scraper stops
+// or starts again matching the site's markup.
+type SourceHealthMsg struct {
+	// Failing are the selectors that stopped matching, none when the source works
+	Failing []string
+}
 
-	if err != nil {
-		return errMsg{err}
+// listPage fetches a page of the category's listing, the category with id 0 is the main listing.
+func listPage(ctx context.Context, source scraper.Source, category scraper.Category, page int) (*scraper.Page, error) {
+	if category.Id == 0 {
+		return source.ListPage(ctx, page)
+	}
+	return source.ListCategoryPage(ctx, category, page)
+}
+
+// fetchEntries fetches the page the user asked for.
+func fetchEntries(ctx context.Context, source scraper.Source, category scraper.Category, page int) tea.Cmd {
+	return func() tea.Msg {
+		p, err := listPage(ctx, source, category, page)
+		// The fetch was superseded by another one or the session ended, nobody is waiting for it
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return errMsg{err, page, category.Id}
+		}
+		return entriesMsg{feedOf(p), category.Id}
+	}
+}
+
+// feedOf returns the state of a tab showing the page.
+func feedOf(p *scraper.Page) Feed {
+	return Feed{
+		Page:        p.Number,
+		Entries:     p.Articles,
+		Failures:    p.Failures,
+		CanContinue: p.CanContinue,
+		CanGoBack:   p.CanGoBack,
+		FetchedAt:   p.FetchedAt,
+		Stale:       p.Stale,
+	}
+}
+
+func fetchCategories(ctx context.Context, source scraper.Source) tea.Cmd {
+	return func() tea.Msg {
+		categories, err := source.Categories(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error("Could not load the categories", "error", err)
+			}
+			return nil
+		}
+		return categoriesMsg(categories)
+	}
+}
+
+type categoriesMsg []scraper.Category
+
+// prefetchPage fetches a page in the background so the source's cache has it when the
+// user moves to it. Failing is fine, the page is fetched again then.
+func prefetchPage(ctx context.Context, source scraper.Source, category scraper.Category, page int) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := listPage(ctx, source, category, page); err != nil && ctx.Err() == nil {
+			log.Debug("Could not prefetch the page", "category", category.Name, "page", page, "error", err)
+		}
+		return nil
 	}
-	return statusMsg(res.StatusCode)
 }
 
-func fetchEntries(page int) tea.Cmd {
+// listenProgress waits for the next progress of the fetch of the page, until ctx is done.
+func listenProgress(ctx context.Context, progress <-chan scraper.Progress, category, page int) tea.Cmd {
 	return func() tea.Msg {
-		entries, canContinue, canGoBack, err := scraper.ScrapePage(page)
+		select {
+		case p := <-progress:
+			return progressMsg{p, page, category, listenProgress(ctx, progress, category, page)}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+type progressMsg struct {
+	progress scraper.Progress
+	page     int
+	category int
+	// next waits for the progress after this one
+	next tea.Cmd
+}
+
+type entriesMsg struct {
+	feed     Feed
+	category int
+}
+
+// revalidatePage fetches again, without showing the spinner, the page on screen
+// because it's stale or couldn't be loaded.
+func revalidatePage(ctx context.Context, source scraper.Source, category scraper.Category, page int) tea.Cmd {
+	return func() tea.Msg {
+		p, err := listPage(ctx, source, category, page)
+		if ctx.Err() != nil {
+			return nil
+		}
 		if err != nil {
-			return errMsg{err}
+			return revalidatedMsg{category: category.Id, page: page, err: err}
 		}
-		return struct {
-			entries     []scraper.Article
-			canContinue bool
-			canGoBack   bool
-			page        int
-		}{entries, canContinue, canGoBack, page}
+		return revalidatedMsg{category: category.Id, page: page, feed: feedOf(p)}
+	}
+}
+
+type revalidateMsg struct {
+	// seq is the revalidation scheduled, only the last one runs
+	seq int
+}
+
+type revalidatedMsg struct {
+	category int
+	page     int
+	feed     Feed
+	err      error
+}
+
+// retryArticles fetches again the articles of the page that couldn't be loaded,
+// sending to progress how many were tried after each one.
+func retryArticles(ctx context.Context, source scraper.Source, category, page int, failures []scraper.ArticleError, progress chan<- scraper.Progress) tea.Cmd {
+	return func() tea.Msg {
+		msg := retriedMsg{page: page, category: category}
+		for i, failure := range failures {
+			article, err := source.FetchArticle(ctx, failure.Link)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				msg.failures = append(msg.failures, scraper.ArticleError{Link: failure.Link, Err: err})
+			} else {
+				msg.entries = append(msg.entries, *article)
+			}
+			select {
+			case progress <- scraper.Progress{Done: i + 1, Total: len(failures)}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		return msg
+	}
+}
+
+type retriedMsg struct {
+	entries  []scraper.Article
+	failures []scraper.ArticleError
+	page     int
+	category int
+}
+
+// startFetch cancels the fetch in flight, if any, and starts fetching the given page of the current tab.
+// The articles are shown as they are scraped, before the whole page arrives.
+func (m *Model) startFetch(page int) tea.Cmd {
+	ctx := m.newFetchContext(page)
+	category := m.currentCategory()
+	progress := make(chan scraper.Progress)
+	m.FetchCmd = tea.Batch(
+		fetchEntries(scraper.WithProgress(ctx, progress), m.Source, category, page),
+		listenProgress(ctx, progress, category.Id, page),
+	)
+	return m.FetchCmd
+}
+
+// startRetry is like startFetch but only fetches the articles of the current page that
+// failed. The entries already loaded stay on screen meanwhile.
+func (m *Model) startRetry() tea.Cmd {
+	ctx := m.newFetchContext(m.CurrentPage)
+	m.Retrying = true
+	category := m.currentCategory().Id
+	progress := make(chan scraper.Progress)
+	m.FetchCmd = tea.Batch(
+		retryArticles(ctx, m.Source, category, m.CurrentPage, m.Failures, progress),
+		listenProgress(ctx, progress, category, m.CurrentPage),
+	)
+	return m.FetchCmd
+}
+
+func (m *Model) newFetchContext(page int) context.Context {
+	m.stopFetch()
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.cancelFetch = cancel
+	m.Fetching = true
+	m.FetchingPage = page
+	m.FetchingCategory = m.currentCategory().Id
+	m.FetchDone = 0
+	m.FetchTotal = 0
+	m.Streamed = 0
+	m.Retrying = false
+	return ctx
+}
+
+// prefetchAdjacent cancels the prefetches in flight and loads the pages before and
+// after the current one in the background, so moving to them doesn't wait for the site.
+func (m *Model) prefetchAdjacent() tea.Cmd {
+	if m.cancelPrefetch != nil {
+		m.cancelPrefetch()
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.cancelPrefetch = cancel
+
+	category := m.currentCategory()
+	var cmds []tea.Cmd
+	if m.CanContinue {
+		cmds = append(cmds, prefetchPage(ctx, m.Source, category, m.CurrentPage+1))
+	}
+	if m.CanGoBack && m.CurrentPage > 0 {
+		cmds = append(cmds, prefetchPage(ctx, m.Source, category, m.CurrentPage-1))
+	}
+	return tea.Batch(cmds...)
+}
+
+const (
+	minRevalidateDelay = 2 * time.Second
+	maxRevalidateDelay = time.Minute
+)
+
+// keepFresh runs after a page is shown: it prefetches the pages around it and, if it's
+// stale, schedules fetching it again.
+func (m *Model) keepFresh() tea.Cmd {
+	if !m.Stale {
+		m.revalidateDelay = 0
+		return m.prefetchAdjacent()
+	}
+	return tea.Batch(m.prefetchAdjacent(), m.scheduleRevalidate())
+}
+
+// scheduleRevalidate fetches again the page on screen after a while, waiting twice as
+// long each time it's still stale or the site still fails. Revalidations scheduled
+// before are dropped.
+func (m *Model) scheduleRevalidate() tea.Cmd {
+	m.revalidateDelay = min(max(2*m.revalidateDelay, minRevalidateDelay), maxRevalidateDelay)
+	m.revalidateSeq++
+	msg := revalidateMsg{seq: m.revalidateSeq}
+	return tea.Tick(m.revalidateDelay, func(time.Time) tea.Msg { return msg })
+}
+
+// retryPending runs once the site is back after being offline: it retries the fetch
+// that failed, or fetches again the page on screen if it's stale, or the articles of
+// it that couldn't be loaded. Nothing is retried while a fetch is in flight.
+func (m *Model) retryPending() tea.Cmd {
+	if m.Fetching {
+		return nil
+	}
+	if banner := m.Banner; banner != nil && banner.fetch && banner.retry != nil {
+		m.Banner = nil
+		m.updateErrorKeys()
+		log.Info("The site is back, retrying the fetch that failed", "error", banner.Err)
+		return banner.retry(m)
+	}
+	if m.Stale || m.FetchErr != nil || len(m.Entries) == 0 {
+		log.Info("The site is back, fetching the entries again", "page", m.CurrentPage)
+		// Drops the revalidation scheduled with the backoff, the site is known to respond now
+		m.revalidateDelay = 0
+		m.revalidateSeq++
+		return revalidatePage(m.ctx, m.Source, m.currentCategory(), m.CurrentPage)
+	}
+	if len(m.Failures) > 0 {
+		log.Infof("The site is back, retrying %d articles of page %d", len(m.Failures), m.CurrentPage)
+		return tea.Batch(m.Spinner.Tick, m.startRetry())
+	}
+	return nil
+}
+
+// showPage fetches the given page of the current tab. The pages prefetched are in the
+// source's cache, so they arrive right away, marked as stale once they expire.
+func (m *Model) showPage(page int) tea.Cmd {
+	return tea.Batch(m.Spinner.Tick, m.startFetch(page))
+}
+
+// storeFeed remembers the page as the one the tab is on.
+func (m *Model) storeFeed(category int, feed Feed) {
+	m.Feeds[category] = feed
+}
+
+func (m *Model) stopFetch() {
+	if m.cancelFetch != nil {
+		m.cancelFetch()
+		m.cancelFetch = nil
 	}
 }
 
 func (m Model) Init() tea.Cmd {
 	// We use Batch to run multiple commands concurrently
-	return tea.Batch(m.Spinner.Tick, checkServer, fetchEntries(m.CurrentPage))
+	return tea.Batch(m.Spinner.Tick, m.FetchCmd, fetchCategories(m.ctx, m.Source))
 }
 
 /*
@@ -65,59 +334,147 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.Spinner, cmd = m.Spinner.Update(msg)
 		return m, cmd
 
-	case statusMsg:
-		m.Status = int(msg)
-		if m.Status == 200 && m.FetchCmd == nil {
-			m.FetchCmd = fetchEntries(m.CurrentPage)
-			return m, tea.Batch(m.Spinner.Tick, m.FetchCmd)
+	case ConnectivityMsg:
+		wasOffline := m.Connectivity.Status == scraper.ConnectivityOffline
+		m.Connectivity = msg.Probe
+		if !wasOffline || msg.Probe.Status == scraper.ConnectivityOffline {
+			return m, nil
+		}
+		return m, m.retryPending()
+
+	case categoriesMsg:
+		m.Categories = msg
+		if m.CurrentCategory > len(m.Categories) {
+			m.CurrentCategory = 0
+		}
+		return m, nil
+
+	case SourceHealthMsg:
+		m.FailingSelectors = msg.Failing
+		if len(m.FailingSelectors) > 0 {
+			log.Warn("The source is degraded", "failing", m.FailingSelectors)
 		}
 		return m, nil
 
+	case NewEntriesMsg:
+		m.NewEntries += msg.Count
+		m.Keys.Refresh.Enabled = m.SelectedEntry == nil
+		return m, nil
+
 	case errMsg:
-		m.Err = msg
+		// The entries on screen stay, the page is fetched again in the background until the site is back
+		log.Error("Could not fetch the entries", "page", msg.page, "error", msg.err)
+		m.FetchErr = msg.err
 		m.Fetching = false
 		m.IsFirstFetch = false
 		m.FetchCmd = nil
-		return m, tea.Quit
-
-	case struct {
-		entries     []scraper.Article
-		canContinue bool
-		canGoBack   bool
-		page        int
-	}:
-		m.Entries = msg.entries
-		m.CanContinue = msg.canContinue
-		m.CanGoBack = msg.canGoBack
-		m.CurrentPage = msg.page
+		m.stopFetch()
+		m.showError(fmt.Sprintf("No se pudo cargar la página %d", msg.page), msg.err, func(m *Model) tea.Cmd {
+			if m.currentCategory().Id != msg.category {
+				return nil
+			}
+			return tea.Batch(m.Spinner.Tick, m.startFetch(msg.page))
+		})
+		m.Banner.fetch = true
+		return m, m.scheduleRevalidate()
+
+	case revalidateMsg:
+		// Only the page on screen is revalidated, when it's stale or nothing could be loaded
+		if msg.seq != m.revalidateSeq || m.Fetching || (!m.Stale && len(m.Entries) > 0) {
+			return m, nil
+		}
+		return m, revalidatePage(m.ctx, m.Source, m.currentCategory(), m.CurrentPage)
+
+	case revalidatedMsg:
+		// The user moved to another page or tab, or fetched one, in the meantime
+		if msg.category != m.currentCategory().Id || msg.page != m.CurrentPage || m.Fetching {
+			return m, nil
+		}
+		if msg.err != nil {
+			log.Warn("Could not revalidate the entries", "page", msg.page, "error", msg.err)
+			m.FetchErr = msg.err
+			return m, m.scheduleRevalidate()
+		}
+		m.fetched()
+		m.storeFeed(msg.category, msg.feed)
+		var selected string
+		if it, ok := m.List.SelectedItem().(item); ok {
+			selected = it.id
+		}
+		m.showFeed(msg.feed)
+		m.selectEntry(selected)
+		return m, m.keepFresh()
+
+	case progressMsg:
+		// Progress of a fetch that was cancelled or already arrived whole
+		if !m.Fetching || msg.page != m.FetchingPage || msg.category != m.FetchingCategory {
+			return m, nil
+		}
+		m.FetchDone = max(m.FetchDone, msg.progress.Done)
+		m.FetchTotal = msg.progress.Total
+		if msg.progress.Article != nil {
+			// The first article replaces the page shown before
+			entries := m.Entries
+			if m.Streamed == 0 {
+				entries = nil
+			}
+			m.Streamed++
+			m.IsFirstFetch = false
+			m.setEntries(append(entries, *msg.progress.Article))
+			if m.Streamed == 1 {
+				m.List.ResetSelected()
+			}
+		}
+		return m, msg.next
+
+	case entriesMsg:
+		// A result for a page or a tab the user already navigated away from
+		if msg.feed.Page != m.FetchingPage || msg.category != m.FetchingCategory || msg.category != m.currentCategory().Id {
+			return m, nil
+		}
+		m.stopFetch()
+		m.fetched()
+		// The front page already has the new entries
+		if msg.feed.Page == 0 && msg.category == 0 && !msg.feed.Stale {
+			m.NewEntries = 0
+			m.Keys.Refresh.Enabled = false
+		}
 		m.Fetching = false
 		m.IsFirstFetch = false
 		m.FetchCmd = nil
 
-		if (m.CanGoBack) {
-			m.Keys.Left.Enabled = true
-		} else {
-			m.Keys.Left.Enabled = false
-		}
-		if (m.CanContinue) {
-			m.Keys.Right.Enabled = true
-		} else {
-			m.Keys.Right.Enabled = false
+		feed := msg.feed
+		m.storeFeed(msg.category, feed)
+		// Keep the user on the entry they moved to while the page was arriving
+		var selected string
+		if it, ok := m.List.SelectedItem().(item); ok && m.Streamed > 0 {
+			selected = it.id
 		}
+		m.showFeed(feed)
+		m.selectEntry(selected)
 
-		sort.Slice(m.Entries, func(i, j int) bool {
-			return m.Entries[i].Date > m.Entries[j].Date
-		})
+		return m, tea.Batch(cmd, m.Spinner.Tick, m.keepFresh())
 
-		items := make([]list.Item, len(m.Entries))
-		for i, entry := range m.Entries {
-			items[i] = item{title: entry.Title, desc: entry.Date}
+	case retriedMsg:
+		if msg.page != m.FetchingPage || msg.page != m.CurrentPage || msg.category != m.currentCategory().Id {
+			return m, nil
 		}
-		m.List.SetItems(items)
-
-		m.List.SetShowPagination(true)
-		m.List.ResetSelected()
-		m.List.ResetFilter()
+		m.stopFetch()
+		m.Fetching = false
+		m.FetchCmd = nil
+		m.Failures = msg.failures
+		m.updateErrorKeys()
+		log.Infof("Retried articles of page %d, %d loaded and %d failed", msg.page, len(msg.entries), len(msg.failures))
+		m.setEntries(append(m.Entries, msg.entries...))
+		m.storeFeed(msg.category, Feed{
+			Page:        m.CurrentPage,
+			Entries:     m.Entries,
+			Failures:    m.Failures,
+			CanContinue: m.CanContinue,
+			CanGoBack:   m.CanGoBack,
+			FetchedAt:   m.FetchedAt,
+			Stale:       m.Stale,
+		})
 
 		return m, tea.Batch(cmd, m.Spinner.Tick)
 
@@ -141,61 +498,82 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.Keys.Down.Binding) && m.Keys.Down.Enabled:
 			m.Viewport.LineDown(1)
 		case key.Matches(msg, m.Keys.Left.Binding) && m.Keys.Left.Enabled:
-			if m.Fetching || !m.CanGoBack {
+			// While fetching we navigate relative to the page being fetched, cancelling it
+			page := m.CurrentPage - 1
+			if m.Fetching {
+				page = m.FetchingPage - 1
+			} else if !m.CanGoBack {
+				return m, nil
+			}
+			if page < 0 {
 				return m, nil
 			}
-			m.Fetching = true
-			m.FetchCmd = fetchEntries(m.CurrentPage - 1)
 			m.LastKey = "←"
-			log.Infof("User navigated to the previous page: %d", m.CurrentPage - 1)
-			return m, m.FetchCmd
+			log.Infof("User navigated to the previous page: %d", page)
+			return m, m.showPage(page)
 		case key.Matches(msg, m.Keys.Right.Binding) && m.Keys.Right.Enabled:
-			if m.Fetching || !m.CanContinue {
+			page := m.CurrentPage + 1
+			if m.Fetching {
+				page = m.FetchingPage + 1
+			}
+			// Only the page on screen is known to have a next one, pages past it may not exist
+			if page > m.CurrentPage+1 || (page == m.CurrentPage+1 && !m.CanContinue) {
 				return m, nil
 			}
-			m.Fetching = true
-			m.FetchCmd = fetchEntries(m.CurrentPage + 1)
 			m.LastKey = "→"
-			log.Infof("User navigated to the next page: %d", m.CurrentPage + 1)
-			return m, tea.Batch(m.Spinner.Tick, m.FetchCmd)
+			log.Infof("User navigated to the next page: %d", page)
+			return m, m.showPage(page)
+		case key.Matches(msg, m.Keys.Refresh.Binding) && m.Keys.Refresh.Enabled:
+			// The new entries are on the front page and on the first page of their
+			// categories, every loaded tab is outdated now
+			m.NewEntries = 0
+			m.Keys.Refresh.Enabled = false
+			clear(m.Feeds)
+			m.LastKey = "r"
+			log.Info("User refreshed the entries")
+			return m, tea.Batch(m.Spinner.Tick, m.startFetch(0))
+		case key.Matches(msg, m.Keys.Retry.Binding) && m.Keys.Retry.Enabled:
+			if m.Fetching {
+				return m, nil
+			}
+			m.LastKey = "R"
+			// The error above the list goes first, it's what the user is looking at
+			if banner := m.Banner; banner != nil && banner.retry != nil {
+				m.Banner = nil
+				m.updateErrorKeys()
+				log.Info("User retried after an error", "error", banner.Err)
+				return m, banner.retry(&m)
+			}
+			log.Infof("User retried %d articles of page %d", len(m.Failures), m.CurrentPage)
+			return m, tea.Batch(m.Spinner.Tick, m.startRetry())
+		case key.Matches(msg, m.Keys.Dismiss.Binding) && m.Keys.Dismiss.Enabled:
+			m.Banner = nil
+			m.updateErrorKeys()
+			m.LastKey = "x"
+			return m, nil
 		case key.Matches(msg, m.Keys.Help.Binding) && m.Keys.Help.Enabled:
 			m.Help.ShowAll = !m.Help.ShowAll
 			return m, nil
 		case key.Matches(msg, m.Keys.Tab.Binding) && m.Keys.Tab.Enabled:
-			m.CurrentCategory = (m.CurrentCategory + 1) % 4
-			return m, nil
+			m.CurrentCategory = (m.CurrentCategory + 1) % (len(m.Categories) + 1)
+			// Each tab remembers the page it was on, the ones never opened start on the first page
+			if feed, ok := m.Feeds[m.currentCategory().Id]; ok {
+				m.stopFetch()
+				m.Fetching = false
+				m.FetchCmd = nil
+				m.showFeed(feed)
+				return m, m.keepFresh()
+			}
+			log.Infof("User opened the category: %s", m.currentCategory().Name)
+			return m, tea.Batch(m.Spinner.Tick, m.startFetch(0))
 		case key.Matches(msg, m.Keys.Enter.Binding) && m.Keys.Enter.Enabled:
-			selectedItem := m.List.SelectedItem().(item)
+			selectedItem, ok := m.List.SelectedItem().(item)
+			if !ok {
+				return m, nil
+			}
 			for _, entry := range m.Entries {
-				if entry.Title == selectedItem.Title() && entry.Date == selectedItem.Description() {
-					m.SelectedEntry = &entry
-					m.Keys.Quit.SetHelp("q", "volver atrás ")
-					m.Keys.Up.SetHelp("↑", "subir ")
-					m.Keys.Down.SetHelp("↓", "bajar ")
-					m.Keys.Enter.Enabled = false
-					m.Keys.Tab.Enabled = false
-					m.Keys.Left.Enabled = false
-					m.Keys.Right.Enabled = false
-					m.List.KeyMap.NextPage.SetEnabled(false)
-					m.List.KeyMap.PrevPage.SetEnabled(false)
-					m.List.KeyMap.CursorUp.SetEnabled(false)
-					m.List.KeyMap.CursorDown.SetEnabled(false)
-
-					r, _ := glamour.NewTermRenderer(
-						glamour.WithStylePath("notty"),
-						glamour.WithWordWrap(m.Width-8),
-					)
-
-					bodyRendered, err := r.Render(m.SelectedEntry.Body)
-					if err != nil {
-						m.Err = err
-						return m, tea.Quit
-					}
-
-					m.Viewport.SetContent(bodyRendered)
-					m.Viewport.GotoTop()
-
-					log.Infof("User selected the article: %s", m.SelectedEntry.Title)
+				if entry.ID == selectedItem.id {
+					m.openEntry(entry)
 					break
 				}
 			}
@@ -210,6 +588,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.Keys.Tab.Enabled = true
 				m.Keys.Left.Enabled = true
 				m.Keys.Right.Enabled = true
+				m.Keys.Refresh.Enabled = m.NewEntries > 0
+				m.updateErrorKeys()
 				m.List.KeyMap.NextPage.SetEnabled(true)
 				m.List.KeyMap.PrevPage.SetEnabled(true)
 				m.List.KeyMap.CursorUp.SetEnabled(true)
@@ -217,6 +597,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			m.Quitting = true
+			m.stopFetch()
 			return m, tea.Quit
 		}
 	}
@@ -227,8 +608,124 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmd, listCmd)
 }
 
+// openEntry renders the article and shows it. If it can't be rendered the list stays
+// on screen with the error.
+func (m *Model) openEntry(entry scraper.Article) {
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStylePath("notty"),
+		glamour.WithWordWrap(m.Width-8),
+	)
+	var bodyRendered string
+	if err == nil {
+		bodyRendered, err = r.Render(articleMarkdown(entry))
+	}
+	if err != nil {
+		log.Error("Could not render the article", "link", entry.Link, "error", err)
+		m.showError("No se pudo mostrar el artículo", err, func(m *Model) tea.Cmd {
+			m.openEntry(entry)
+			return nil
+		})
+		return
+	}
+
+	m.SelectedEntry = &entry
+	m.Keys.Quit.SetHelp("q", "volver atrás ")
+	m.Keys.Up.SetHelp("↑", "subir ")
+	m.Keys.Down.SetHelp("↓", "bajar ")
+	m.Keys.Enter.Enabled = false
+	m.Keys.Tab.Enabled = false
+	m.Keys.Left.Enabled = false
+	m.Keys.Right.Enabled = false
+	m.Keys.Refresh.Enabled = false
+	m.updateErrorKeys()
+	m.List.KeyMap.NextPage.SetEnabled(false)
+	m.List.KeyMap.PrevPage.SetEnabled(false)
+	m.List.KeyMap.CursorUp.SetEnabled(false)
+	m.List.KeyMap.CursorDown.SetEnabled(false)
+
+	m.Viewport.SetContent(bodyRendered)
+	m.Viewport.GotoTop()
+
+	log.Infof("User selected the article: %s", m.SelectedEntry.Title)
+}
+
+// showError shows a recoverable error above the list, retry repeats what failed and
+// may be nil. It replaces the error shown before.
+func (m *Model) showError(message string, err error, retry func(m *Model) tea.Cmd) {
+	m.Banner = &ErrorBanner{Message: message, Err: err, retry: retry}
+	m.updateErrorKeys()
+}
+
+// fetched clears the errors of fetching the entries, they were fetched now.
+func (m *Model) fetched() {
+	m.FetchErr = nil
+	if m.Banner != nil && m.Banner.fetch {
+		m.Banner = nil
+	}
+	m.updateErrorKeys()
+}
+
+// updateErrorKeys enables retrying and dismissing when there are errors on screen,
+// they're hidden while reading an article.
+func (m *Model) updateErrorKeys() {
+	reading := m.SelectedEntry != nil
+	m.Keys.Retry.Enabled = !reading && (len(m.Failures) > 0 || (m.Banner != nil && m.Banner.retry != nil))
+	m.Keys.Dismiss.Enabled = !reading && m.Banner != nil
+}
+
+// showFeed shows the page of a tab and enables the keys that apply to it.
+func (m *Model) showFeed(feed Feed) {
+	m.Failures = feed.Failures
+	m.CanContinue = feed.CanContinue
+	m.CanGoBack = feed.CanGoBack
+	m.CurrentPage = feed.Page
+	m.FetchedAt = feed.FetchedAt
+	m.Stale = feed.Stale
+	m.updateErrorKeys()
+
+	if (m.CanGoBack) {
+		m.Keys.Left.Enabled = true
+	} else {
+		m.Keys.Left.Enabled = false
+	}
+	if (m.CanContinue) {
+		m.Keys.Right.Enabled = true
+	} else {
+		m.Keys.Right.Enabled = false
+	}
+
+	m.setEntries(feed.Entries)
+	m.List.ResetSelected()
+}
+
+// setEntries sorts the entries from the newest to the oldest and shows them in the list.
+func (m *Model) setEntries(entries []scraper.Article) {
+	m.Entries = entries
+	sort.Slice(m.Entries, func(i, j int) bool {
+		return m.Entries[i].Date.After(m.Entries[j].Date)
+	})
+
+	m.List.SetItems(entriesToListItems(m.Entries))
+
+	m.List.SetShowPagination(true)
+	m.List.ResetFilter()
+}
+
+// selectEntry moves the cursor of the list to the entry with the given id, if it's there.
+func (m *Model) selectEntry(id string) {
+	for i, entry := range m.Entries {
+		if entry.ID == id {
+			m.List.Select(i)
+			return
+		}
+	}
+}
+
+// dateLayout is how the dates of the entries are shown
+const dateLayout = "2006-01-02 15:04"
+
 type item struct {
-	title, desc string
+	id, title, desc string
 }
 
 func (i item) Title() string       { return i.title }
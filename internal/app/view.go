@@ -1,25 +1,23 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/bubbles/list"
 
+	"qpc-tui/internal/body"
 	"qpc-tui/internal/scraper"
 )
 
 func (m Model) View() string {
-	if m.Err != nil {
-			return fmt.Sprintf("\nOcurrió un error: %v\n\n", m.Err)
-	}
-
-	navigationMenuItems := []string{
-			"Todas",
-			"Policiales",
-			"Sociedad",
-			"Automotores",
+	// The first tab shows every category, the rest are the ones discovered on the site
+	navigationMenuItems := []string{"Todas"}
+	for _, category := range m.Categories {
+			navigationMenuItems = append(navigationMenuItems, category.Name)
 	}
 
 	// Render the navigation menu, it shows the current category and the selected entry
@@ -28,15 +26,7 @@ func (m Model) View() string {
 			if m.SelectedEntry != nil {
 					tabItems = append(tabItems, m.renderer.NewStyle().Foreground(lipgloss.Color("8")).Render(item))
 			} else if i == m.CurrentCategory {
-					if (m.CurrentCategory == 2) {
-						tabItems = append(tabItems, m.renderer.NewStyle().Foreground(lipgloss.Color("4")).Render(item))
-					} else if (m.CurrentCategory == 1) {
-						tabItems = append(tabItems, m.renderer.NewStyle().Foreground(lipgloss.Color("1")).Render(item))
-					} else if (m.CurrentCategory == 3) {
-						tabItems = append(tabItems, m.renderer.NewStyle().Foreground(lipgloss.Color("3")).Render(item))
-					} else {
-						tabItems = append(tabItems, m.renderer.NewStyle().Foreground(lipgloss.Color("8")).Render(item))
-					}
+					tabItems = append(tabItems, m.renderer.NewStyle().Foreground(tabColor(i)).Render(item))
 			} else {
 					tabItems = append(tabItems, m.renderer.NewStyle().Render(item))
 			}
@@ -74,25 +64,96 @@ func (m Model) View() string {
 			Align(lipgloss.Center).
 			Render(titleAndNavigation)
 
+	// The scraper can't read the site anymore, most likely it changed its design
+	if len(m.FailingSelectors) > 0 && m.SelectedEntry == nil {
+		titleAndNavigation = lipgloss.JoinVertical(
+			lipgloss.Left,
+			titleAndNavigation,
+			m.renderer.NewStyle().Foreground(lipgloss.Color("9")).MarginLeft(4).Render("⚠ El sitio cambió su diseño, las entradas pueden faltar o estar incompletas"),
+		)
+	}
+
+	// The entries are an older copy, shown while the site is slow or down
+	if m.Stale && m.SelectedEntry == nil && len(m.Entries) > 0 {
+		banner := "⟳ desactualizado " + ago(m.FetchedAt) + " — actualizando en segundo plano"
+		if m.FetchErr != nil {
+			banner = "⚠ desactualizado " + ago(m.FetchedAt) + " — " + fetchErrorText(m.FetchErr) + ", se reintentará en segundo plano"
+		}
+		titleAndNavigation = lipgloss.JoinVertical(
+			lipgloss.Left,
+			titleAndNavigation,
+			m.renderer.NewStyle().Foreground(lipgloss.Color("3")).MarginLeft(4).Render(banner),
+		)
+	}
+
+	// Let the user know there are new entries on the site, they're loaded on demand
+	if m.NewEntries > 0 && m.SelectedEntry == nil {
+		banner := fmt.Sprintf("%d nuevas entradas — presioná r para actualizar", m.NewEntries)
+		if m.NewEntries == 1 {
+			banner = "1 nueva entrada — presioná r para actualizar"
+		}
+		titleAndNavigation = lipgloss.JoinVertical(
+			lipgloss.Left,
+			titleAndNavigation,
+			m.renderer.NewStyle().Foreground(lipgloss.Color("3")).MarginLeft(4).Render(banner),
+		)
+	}
+
+	// Some articles of the page couldn't be loaded, the user can retry them
+	if len(m.Failures) > 0 && m.SelectedEntry == nil && !m.Fetching {
+		banner := fmt.Sprintf(
+			"%d entradas (%d no se pudieron cargar) — presioná R para reintentar",
+			len(m.Entries)+len(m.Failures),
+			len(m.Failures),
+		)
+		titleAndNavigation = lipgloss.JoinVertical(
+			lipgloss.Left,
+			titleAndNavigation,
+			m.renderer.NewStyle().Foreground(lipgloss.Color("1")).MarginLeft(4).Render(banner),
+		)
+	}
+
+	// A recoverable error, the user can retry what failed or dismiss it
+	if m.Banner != nil && m.SelectedEntry == nil {
+		banner := "✖ " + m.Banner.Message + ": " + fetchErrorText(m.Banner.Err)
+		if m.Banner.retry != nil {
+			banner += " — presioná R para reintentar o x para descartar"
+		} else {
+			banner += " — presioná x para descartar"
+		}
+		titleAndNavigation = lipgloss.JoinVertical(
+			lipgloss.Left,
+			titleAndNavigation,
+			m.renderer.NewStyle().Foreground(lipgloss.Color("9")).MarginLeft(4).MaxWidth(m.Width-4).Render(banner),
+		)
+	}
+
 	titleAndNavigationHeight := len(strings.Split(titleAndNavigation, "\n"))
 
 	var content string
-	if m.Fetching {
-		content = lipgloss.JoinHorizontal(lipgloss.Center, m.Spinner.View(), "  Obteniendo entradas...")
+	if m.Fetching && m.Streamed == 0 && !m.Retrying {
+		content = m.fetchingView()
 	} else if m.Quitting {
 		content = "Bye!"
 	} else if m.SelectedEntry != nil {
 		content = m.Viewport.View()
-	} else if m.Status > 0 && len(m.Entries) > 0 {
-		filteredEntries := filterEntriesByCategory(m.Entries, m.CurrentCategory)
-		m.List.SetItems(entriesToListItems(filteredEntries))
+	} else if len(m.Entries) > 0 {
+		m.List.SetItems(entriesToListItems(m.Entries))
 		m.List.SetDelegate(NewCustomDelegate(m.renderer, m))
 		content = m.List.View()
+		// The rest of the page is still arriving
+		if m.Fetching && !m.Retrying {
+			content = lipgloss.JoinVertical(lipgloss.Left, m.renderer.NewStyle().MarginLeft(2).Render(m.fetchingView()), content)
+		}
+	} else if m.FetchErr != nil {
+		content = m.renderer.NewStyle().Foreground(lipgloss.Color("1")).MarginLeft(2).Render(
+			"No se pudieron obtener las entradas: " + fetchErrorText(m.FetchErr) + ", se reintentará en segundo plano",
+		)
 	} else {
-		content = lipgloss.JoinHorizontal(lipgloss.Center, m.Spinner.View(), "  Obteniendo entradas...")
+		content = m.fetchingView()
 	}
 
-	helpView := m.renderer.NewStyle().MarginLeft(1).Render(m.Help.View(m.Keys))
+	helpView := m.statusBar(m.renderer.NewStyle().MarginLeft(1).Render(m.Help.View(m.Keys)))
 
 	contentHeight := m.Height-3-titleAndNavigationHeight
 	if m.Help.ShowAll {
@@ -114,7 +175,7 @@ if (m.IsFirstFetch) {
         m.Height,
         lipgloss.Center,
         lipgloss.Center,
-        lipgloss.JoinHorizontal(lipgloss.Center, m.Spinner.View(), "  Obteniendo entradas..."),
+        m.fetchingView(),
     )
     return lipgloss.JoinVertical(
         lipgloss.Left,
@@ -140,37 +201,143 @@ if (m.IsFirstFetch) {
 	)
 }
 
-func filterEntriesByCategory(entries []scraper.Article, currentCategory int) []scraper.Article {
-	if currentCategory == 0 {
-			return entries
+// fetchingView is the spinner shown while fetching, with how many articles of the page arrived.
+func (m Model) fetchingView() string {
+	text := "  Obteniendo entradas..."
+	if m.Fetching && m.FetchTotal > 0 {
+		text += fmt.Sprintf(" %d/%d", m.FetchDone, m.FetchTotal)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Center, m.Spinner.View(), text)
+}
+
+// statusBar puts how the site responds at the right of the help.
+func (m Model) statusBar(helpView string) string {
+	segment := m.connectivityView()
+	// The articles that failed are retried with the list on screen
+	if m.Fetching && m.Retrying {
+		retrying := fmt.Sprintf("Reintentando artículos %d/%d", m.FetchDone, len(m.Failures))
+		segment = lipgloss.JoinHorizontal(lipgloss.Top, m.Spinner.View(), retrying, "  ", segment)
 	}
+	gap := max(m.Width-lipgloss.Width(helpView)-lipgloss.Width(segment)-1, 2)
+	return lipgloss.JoinHorizontal(lipgloss.Top, helpView, strings.Repeat(" ", gap), segment)
+}
 
-	var filteredEntries []scraper.Article
-	for _, entry := range entries {
-			if entry.CategoryId == getCategoryIdByCategory(currentCategory) {
-					filteredEntries = append(filteredEntries, entry)
-			}
+// connectivityView is the state of the site as of the last probe, with how long it took to respond.
+func (m Model) connectivityView() string {
+	probe := m.Connectivity
+	style := m.renderer.NewStyle()
+	switch probe.Status {
+	case scraper.ConnectivityOnline:
+		return style.Foreground(lipgloss.Color("10")).Render("● en línea · " + latency(probe.Latency))
+	case scraper.ConnectivityDegraded:
+		if probe.StatusCode >= 400 {
+			return style.Foreground(lipgloss.Color("3")).Render(fmt.Sprintf("◐ con problemas · HTTP %d", probe.StatusCode))
+		}
+		return style.Foreground(lipgloss.Color("3")).Render("◐ lento · " + latency(probe.Latency))
+	case scraper.ConnectivityOffline:
+		return style.Foreground(lipgloss.Color("9")).Render("○ sin conexión")
+	default:
+		return style.Foreground(lipgloss.Color("8")).Render("○ comprobando conexión...")
+	}
+}
+
+// latency is a response time like "120 ms" or "3.4 s".
+func latency(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%d ms", d.Milliseconds())
 	}
-	return filteredEntries
+	return fmt.Sprintf("%.1f s", d.Seconds())
 }
 
-func getCategoryIdByCategory(category int) int {
-	switch category {
-	case 1:
-			return 8 // Policiales
-	case 2:
-			return 48 // Sociedad
-	case 3:
-			return 75 // Automotores
+// ago is how long ago t was, like "hace 12 min".
+func ago(t time.Time) string {
+	if t.IsZero() {
+		return "desde hace un rato"
+	}
+	switch d := time.Since(t); {
+	case d < time.Minute:
+		return "hace menos de un minuto"
+	case d < time.Hour:
+		return fmt.Sprintf("hace %d min", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("hace %d h", int(d.Hours()))
 	default:
-			return 0 // All
+		return fmt.Sprintf("hace %d días", int(d.Hours()/24))
+	}
+}
+
+// fetchErrorText explains to the user why the entries couldn't be fetched.
+func fetchErrorText(err error) string {
+	if errors.Is(err, scraper.ErrCircuitOpen) {
+		return "el sitio no responde"
+	}
+	return err.Error()
+}
+
+// currentCategory returns the category of the selected tab, the "Todas" tab has the id 0.
+func (m Model) currentCategory() scraper.Category {
+	if m.CurrentCategory < 1 || m.CurrentCategory > len(m.Categories) {
+			return scraper.Category{}
 	}
+	return m.Categories[m.CurrentCategory-1]
+}
+
+// Colors of the category tabs, in the order the site lists them
+var categoryColors = []lipgloss.Color{"1", "4", "3", "2", "5", "6"}
+
+// tabColor returns the color of the tab when it's selected.
+func tabColor(tab int) lipgloss.Color {
+	if tab == 0 {
+			return lipgloss.Color("8")
+	}
+	return categoryColors[(tab-1)%len(categoryColors)]
 }
 
 func entriesToListItems(entries []scraper.Article) []list.Item {
 	items := make([]list.Item, len(entries))
 	for i, entry := range entries {
-			items[i] = item{title: entry.Title, desc: entry.Date}
+			items[i] = item{id: entry.ID, title: entry.Title, desc: entry.Date.Format(dateLayout)}
 	}
 	return items
-}
\ No newline at end of file
+}
+
+// articleMarkdown is what the reader shows: the title, the lead and the body, followed
+// by the author, the tags and the links to the media the terminal can't show.
+func articleMarkdown(entry scraper.Article) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", body.EscapeMarkdown(entry.Title))
+	if entry.Lead != "" {
+		fmt.Fprintf(&b, "*%s*\n\n", body.EscapeMarkdown(entry.Lead))
+	}
+	b.WriteString(entry.Body.Markdown())
+	if entry.Author != "" {
+		fmt.Fprintf(&b, "\n\nPor **%s**", body.EscapeMarkdown(entry.Author))
+	}
+	if len(entry.Tags) > 0 {
+		tags := make([]string, len(entry.Tags))
+		for i, tag := range entry.Tags {
+			tags[i] = body.EscapeMarkdown(tag)
+		}
+		fmt.Fprintf(&b, "\n\nEtiquetas: %s", strings.Join(tags, ", "))
+	}
+
+	if entry.ImageURL == "" && len(entry.Images) == 0 && len(entry.Videos) == 0 {
+		return b.String()
+	}
+	b.WriteString("\n\n---\n\n## Multimedia\n")
+	if entry.ImageURL != "" {
+		caption := entry.ImageCaption
+		if caption == "" {
+			caption = "Imagen principal"
+		}
+		fmt.Fprintf(&b, "\n- 🖼 %s: %s", body.EscapeMarkdown(caption), entry.ImageURL)
+	}
+	for _, image := range entry.Images {
+		fmt.Fprintf(&b, "\n- 🖼 Imagen: %s", image)
+	}
+	for _, video := range entry.Videos {
+		fmt.Fprintf(&b, "\n- ▶ Video: %s", video)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
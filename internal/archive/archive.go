@@ -0,0 +1,375 @@
+/*
+	Package archive persists every scraped article to a local file, so the history
+	survives restarts and articles that rotated off the site's listing can still be read.
+
+	The file is an append-only log with one JSON encoded article per line, the last line
+	for a link wins. It's replayed into memory on Open, where the indexes live.
+*/
+
+package archive
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"qpc-tui/internal/body"
+	"qpc-tui/internal/scraper"
+	"qpc-tui/internal/spanishdate"
+)
+
+type Archive struct {
+	mu   sync.RWMutex
+	file *os.File
+
+	articles map[string]scraper.Article
+	// Links sorted from the newest to the oldest article
+	byDate     []string
+	byCategory map[int][]string
+}
+
+// Open loads the archive stored at path, creating it if it doesn't exist.
+func Open(path string) (*Archive, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive{
+		file:       file,
+		articles:   make(map[string]scraper.Article),
+		byCategory: make(map[int][]string),
+	}
+	if err := a.load(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("loading archive %s: %w", path, err)
+	}
+	return a, nil
+}
+
+func (a *Archive) load() error {
+	reader := bufio.NewReader(a.file)
+	line := 0
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			line++
+			if article, jsonErr := decodeArticle(data); jsonErr != nil {
+				// Most likely a write interrupted by a crash, the rest of the log is still good
+				log.Warn("Skipping corrupt archive line", "line", line, "error", jsonErr)
+			} else {
+				a.index(article)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			// Terminate a truncated last line so the next write starts on its own line
+			if len(data) > 0 && data[len(data)-1] != '\n' {
+				if _, err := a.file.Write([]byte("\n")); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Put stores the article, replacing the one with the same link if it changed.
+func (a *Archive) Put(article scraper.Article) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if existing, ok := a.articles[article.Link]; ok && sameArticle(existing, article) {
+		return nil
+	}
+
+	data, err := json.Marshal(article)
+	if err != nil {
+		return err
+	}
+	if _, err := a.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	a.index(article)
+	return nil
+}
+
+// Get returns the article with the given link.
+func (a *Archive) Get(link string) (scraper.Article, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	article, ok := a.articles[link]
+	return article, ok
+}
+
+// Len returns how many articles are stored.
+func (a *Archive) Len() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.articles)
+}
+
+// Latest returns up to limit articles starting at offset, from the newest to the oldest.
+func (a *Archive) Latest(offset, limit int) []scraper.Article {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.collect(a.byDate, offset, limit)
+}
+
+// ByCategory is like Latest but only returns articles of the given category.
+func (a *Archive) ByCategory(categoryId, offset, limit int) []scraper.Article {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.collect(a.byCategory[categoryId], offset, limit)
+}
+
+// Between returns the articles published between from and to (inclusive), from the newest to the oldest.
+func (a *Archive) Between(from, to time.Time) []scraper.Article {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	start := sort.Search(len(a.byDate), func(i int) bool { return !a.articles[a.byDate[i]].Date.After(to) })
+	end := sort.Search(len(a.byDate), func(i int) bool { return a.articles[a.byDate[i]].Date.Before(from) })
+	if end < start {
+		// from is after to
+		return nil
+	}
+	return a.collect(a.byDate[start:end], 0, end-start)
+}
+
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+func (a *Archive) collect(links []string, offset, limit int) []scraper.Article {
+	if offset >= len(links) {
+		return nil
+	}
+	links = links[offset:]
+	if limit < len(links) {
+		links = links[:limit]
+	}
+	articles := make([]scraper.Article, len(links))
+	for i, link := range links {
+		articles[i] = a.articles[link]
+	}
+	return articles
+}
+
+// index adds the article to the in memory indexes, a.mu must be held for writing.
+func (a *Archive) index(article scraper.Article) {
+	if existing, ok := a.articles[article.Link]; ok {
+		a.byDate = a.remove(a.byDate, existing)
+		a.byCategory[existing.CategoryId] = a.remove(a.byCategory[existing.CategoryId], existing)
+	}
+	a.articles[article.Link] = article
+	a.byDate = a.insert(a.byDate, article)
+	a.byCategory[article.CategoryId] = a.insert(a.byCategory[article.CategoryId], article)
+}
+
+// position returns where the article goes in a list of links sorted from the newest to the oldest.
+func (a *Archive) position(links []string, article scraper.Article) int {
+	return sort.Search(len(links), func(i int) bool {
+		other := a.articles[links[i]]
+		if !other.Date.Equal(article.Date) {
+			return other.Date.Before(article.Date)
+		}
+		return other.Link >= article.Link
+	})
+}
+
+func (a *Archive) insert(links []string, article scraper.Article) []string {
+	i := a.position(links, article)
+	links = append(links, "")
+	copy(links[i+1:], links[i:])
+	links[i] = article.Link
+	return links
+}
+
+func (a *Archive) remove(links []string, article scraper.Article) []string {
+	i := a.position(links, article)
+	if i < len(links) && links[i] == article.Link {
+		links = append(links[:i], links[i+1:]...)
+	}
+	return links
+}
+
+// decodeArticle decodes a line of the log. Lines written before articles had an ID
+// have the date formatted as "2006-01-02 15:04:05", and the ones written before the
+// body had blocks have it as markdown, those are converted.
+func decodeArticle(data []byte) (scraper.Article, error) {
+	var article scraper.Article
+	err := json.Unmarshal(data, &article)
+	if err == nil {
+		return article, nil
+	}
+
+	var legacy struct {
+		scraper.Article
+		Date json.RawMessage `json:"date"`
+		Body json.RawMessage `json:"body"`
+	}
+	if legacyErr := json.Unmarshal(data, &legacy); legacyErr != nil {
+		return article, err
+	}
+	article = legacy.Article
+	if json.Unmarshal(legacy.Date, &article.Date) != nil {
+		var date string
+		if json.Unmarshal(legacy.Date, &date) != nil {
+			return article, err
+		}
+		parsed, legacyErr := time.ParseInLocation("2006-01-02 15:04:05", date, spanishdate.Location)
+		if legacyErr != nil {
+			return article, err
+		}
+		article.Date = parsed
+	}
+	if len(legacy.Body) > 0 && json.Unmarshal(legacy.Body, &article.Body) != nil {
+		var markdown string
+		if json.Unmarshal(legacy.Body, &markdown) != nil {
+			return article, err
+		}
+		article.Body = markdownBody(markdown, article.Title)
+	}
+	if article.ID == "" {
+		article.ID = scraper.ArticleID(article.Link)
+	}
+	return article, nil
+}
+
+// markdownBody converts the markdown bodies of the old lines, which started with
+// the title, to blocks. Only the headings and quotes are told apart, the rest of the
+// paragraphs keep their text without the markdown formatting, except for the links.
+func markdownBody(markdown, title string) body.Blocks {
+	var blocks body.Blocks
+	for _, paragraph := range strings.Split(markdown, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		switch {
+		case paragraph == "" || paragraph == "# "+title:
+		case strings.HasPrefix(paragraph, "#"):
+			text, links := markdownText(strings.TrimLeft(paragraph, "#"))
+			blocks = append(blocks, body.Block{
+				Kind:  body.Heading,
+				Level: len(paragraph) - len(strings.TrimLeft(paragraph, "#")),
+				Text:  text,
+				Links: links,
+			})
+		case strings.HasPrefix(paragraph, "> "):
+			text, links := markdownText(strings.TrimPrefix(paragraph, "> "))
+			blocks = append(blocks, body.Block{Kind: body.Quote, Text: text, Links: links})
+		default:
+			text, links := markdownText(paragraph)
+			blocks = append(blocks, body.Block{Kind: body.Paragraph, Text: text, Links: links})
+		}
+	}
+	return blocks
+}
+
+var (
+	markdownLinkRegexp   = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
+	markdownEscapeRegexp = regexp.MustCompile("\\\\([!-/:-@\\[-`{-~])")
+)
+
+// markdownText returns the text of a paragraph of the old markdown bodies, without
+// the escapes and the bold markers, and the links in it.
+func markdownText(markdown string) (string, []body.Link) {
+	clean := func(s string) string {
+		s = strings.ReplaceAll(s, "**", "")
+		return markdownEscapeRegexp.ReplaceAllString(s, "$1")
+	}
+	markdown = strings.TrimSpace(markdown)
+	var text strings.Builder
+	var links []body.Link
+	end := 0
+	for _, match := range markdownLinkRegexp.FindAllStringSubmatchIndex(markdown, -1) {
+		text.WriteString(clean(markdown[end:match[0]]))
+		start := text.Len()
+		text.WriteString(clean(markdown[match[2]:match[3]]))
+		if text.Len() > start {
+			links = append(links, body.Link{Start: start, End: text.Len(), URL: markdown[match[4]:match[5]]})
+		}
+		end = match[1]
+	}
+	text.WriteString(clean(markdown[end:]))
+	return text.String(), links
+}
+
+// sameArticle reports if storing b would change nothing, comparing the dates with
+// Equal since the ones read back from the log have another location.
+func sameArticle(a, b scraper.Article) bool {
+	if !a.Date.Equal(b.Date) {
+		return false
+	}
+	a.Date, b.Date = time.Time{}, time.Time{}
+	return reflect.DeepEqual(a, b)
+}
+
+/*
+	Wrap returns a Source that stores in the archive every article the given source
+	scrapes. Failing to store an article is logged, it doesn't fail the scrape.
+*/
+func (a *Archive) Wrap(source scraper.Source) scraper.Source {
+	return &archivingSource{Source: source, archive: a}
+}
+
+type archivingSource struct {
+	scraper.Source
+	archive *Archive
+}
+
+func (s *archivingSource) ListPage(ctx context.Context, page int) (*scraper.Page, error) {
+	p, err := s.Source.ListPage(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+	for _, article := range p.Articles {
+		s.put(article)
+	}
+	return p, nil
+}
+
+func (s *archivingSource) ListCategoryPage(ctx context.Context, category scraper.Category, page int) (*scraper.Page, error) {
+	p, err := s.Source.ListCategoryPage(ctx, category, page)
+	if err != nil {
+		return nil, err
+	}
+	for _, article := range p.Articles {
+		s.put(article)
+	}
+	return p, nil
+}
+
+func (s *archivingSource) FetchArticle(ctx context.Context, link string) (*scraper.Article, error) {
+	article, err := s.Source.FetchArticle(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	s.put(*article)
+	return article, nil
+}
+
+func (s *archivingSource) put(article scraper.Article) {
+	if err := s.archive.Put(article); err != nil {
+		log.Error("Could not archive article", "link", article.Link, "error", err)
+	}
+}
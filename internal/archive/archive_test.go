@@ -0,0 +1,201 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"qpc-tui/internal/body"
+	"qpc-tui/internal/scraper"
+)
+
+var day = time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+func article(link string, hours, category int) scraper.Article {
+	return scraper.Article{
+		ID:         scraper.ArticleID(link),
+		Link:       link,
+		Title:      "Nota " + link,
+		Date:       day.Add(time.Duration(hours) * time.Hour),
+		CategoryId: category,
+	}
+}
+
+func open(t *testing.T, path string) *Archive {
+	t.Helper()
+	a, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+	return a
+}
+
+func links(articles []scraper.Article) string {
+	l := make([]string, len(articles))
+	for i, article := range articles {
+		l[i] = article.Link
+	}
+	return strings.Join(l, " ")
+}
+
+func lines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(data), "\n")
+}
+
+func TestPut(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+	a := open(t, path)
+
+	for _, article := range []scraper.Article{article("/b", 1, 8), article("/a", 1, 8), article("/c", 2, 75), article("/d", 0, 8)} {
+		if err := a.Put(article); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	// The articles published at the same time are sorted by link
+	if got := links(a.Latest(0, 10)); got != "/c /a /b /d" {
+		t.Errorf("Latest = %s, want /c /a /b /d", got)
+	}
+	if got := links(a.Latest(1, 2)); got != "/a /b" {
+		t.Errorf("Latest(1, 2) = %s, want /a /b", got)
+	}
+	if got := a.Latest(4, 1); got != nil {
+		t.Errorf("Latest past the end = %v, want nothing", got)
+	}
+	if got := links(a.ByCategory(8, 0, 10)); got != "/a /b /d" {
+		t.Errorf("ByCategory(8) = %s, want /a /b /d", got)
+	}
+
+	// Storing the same article again writes nothing
+	if err := a.Put(article("/a", 1, 8)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if n := lines(t, path); n != 4 {
+		t.Errorf("the log has %d lines, want 4", n)
+	}
+
+	// A changed article moves in the indexes
+	moved := article("/a", 3, 75)
+	moved.Title = "Nota actualizada"
+	if err := a.Put(moved); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got := links(a.Latest(0, 10)); got != "/a /c /b /d" {
+		t.Errorf("Latest after the update = %s, want /a /c /b /d", got)
+	}
+	if got, want := links(a.ByCategory(8, 0, 10)), "/b /d"; got != want {
+		t.Errorf("ByCategory(8) after the update = %s, want %s", got, want)
+	}
+	if got, want := links(a.ByCategory(75, 0, 10)), "/a /c"; got != want {
+		t.Errorf("ByCategory(75) after the update = %s, want %s", got, want)
+	}
+	if got, ok := a.Get("/a"); !ok || got.Title != "Nota actualizada" {
+		t.Errorf("Get = %+v, %v, want the updated article", got, ok)
+	}
+	if _, ok := a.Get("/z"); ok {
+		t.Error("Get of an article never stored succeeded")
+	}
+
+	// The last line of each link wins when the log is replayed
+	a.Close()
+	reopened := open(t, path)
+	if reopened.Len() != 4 || links(reopened.Latest(0, 10)) != "/a /c /b /d" {
+		t.Errorf("reopened archive has %d articles: %s, want the 4 of before", reopened.Len(), links(reopened.Latest(0, 10)))
+	}
+	if got, _ := reopened.Get("/a"); !got.Date.Equal(moved.Date) || got.Title != moved.Title {
+		t.Errorf("reopened Get = %+v, want the updated article", got)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	a := open(t, filepath.Join(t.TempDir(), "archive.jsonl"))
+	for hours, link := range []string{"/0", "/1", "/2", "/3"} {
+		if err := a.Put(article(link, hours, 8)); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	tests := []struct {
+		from, to int
+		want     string
+	}{
+		{1, 2, "/2 /1"},
+		{0, 3, "/3 /2 /1 /0"},
+		{-5, 0, "/0"},
+		{4, 9, ""},
+		{2, 1, ""},
+		{3, 0, ""},
+	}
+	for _, tt := range tests {
+		from, to := day.Add(time.Duration(tt.from)*time.Hour), day.Add(time.Duration(tt.to)*time.Hour)
+		if got := links(a.Between(from, to)); got != tt.want {
+			t.Errorf("Between(%d, %d) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestOpenRecovers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+	a := open(t, path)
+	if err := a.Put(article("/a", 0, 8)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	a.Close()
+
+	// A corrupt line in the middle and a write interrupted halfway through the last one
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("not json\n{\"link\":\"/b\",\"title\":")
+	f.Close()
+
+	a = open(t, path)
+	if a.Len() != 1 {
+		t.Fatalf("Len = %d, want the only good line", a.Len())
+	}
+	if err := a.Put(article("/c", 1, 8)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	a.Close()
+
+	// The new line didn't end up glued to the truncated one
+	a = open(t, path)
+	if got := links(a.Latest(0, 10)); got != "/c /a" {
+		t.Errorf("Latest = %s, want /c /a", got)
+	}
+}
+
+func TestOpenLegacyLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+	legacy := `{"title":"Vieja","link":"https://example.com/nota/1/vieja/","date":"2023-05-01 10:30:00",` +
+		`"body":"# Vieja\n\n## Subtítulo\n\n> Una cita\n\nVer el **informe** en [el sitio](https://example.com/x) 1\\. no es lista"}` + "\n"
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := open(t, path)
+	got, ok := a.Get("https://example.com/nota/1/vieja/")
+	if !ok {
+		t.Fatal("the legacy line wasn't loaded")
+	}
+	if got.ID != "nota/1/vieja" || got.Date.Year() != 2023 || got.Date.Hour() != 10 {
+		t.Errorf("ID, Date = %q, %v, want the ones derived from the link and the old date", got.ID, got.Date)
+	}
+	want := body.Blocks{
+		{Kind: body.Heading, Level: 2, Text: "Subtítulo"},
+		{Kind: body.Quote, Text: "Una cita"},
+		{Kind: body.Paragraph, Text: "Ver el informe en el sitio 1. no es lista", Links: []body.Link{{Start: 18, End: 26, URL: "https://example.com/x"}}},
+	}
+	if !reflect.DeepEqual(got.Body, want) {
+		t.Errorf("Body = %+v, want %+v", got.Body, want)
+	}
+}
@@ -0,0 +1,480 @@
+/*
+	Package body models the body of an article as an ordered list of typed blocks, so
+	every front end and exporter renders it the way it needs instead of parsing
+	markdown. Blocks encode to JSON as they are, Markdown, Text and HTML render them.
+*/
+
+package body
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type Kind string
+
+const (
+	Heading   Kind = "heading"
+	Paragraph Kind = "paragraph"
+	Quote     Kind = "quote"
+	Image     Kind = "image"
+	Embed     Kind = "embed"
+	List      Kind = "list"
+	Table     Kind = "table"
+	Gallery   Kind = "gallery"
+	// Social is a post of a social network embedded in the body, like a tweet
+	Social Kind = "social"
+)
+
+// videoLinkRegexp matches the links to the video players articles embed.
+var videoLinkRegexp = regexp.MustCompile(`(youtube\.com/(embed|watch)|youtu\.be/|player\.vimeo\.com|vimeo\.com/\d|facebook\.com/plugins/video|facebook\.com/.+/videos/)`)
+
+// socialNetworks are the classes of the blockquotes the social networks' embed
+// scripts turn into posts, and the names of the networks.
+var socialNetworks = []struct{ class, name string }{
+	{"twitter-tweet", "Twitter"},
+	{"instagram-media", "Instagram"},
+	{"tiktok-embed", "TikTok"},
+}
+
+// Block is a single piece of the body, which fields are set depends on its Kind.
+type Block struct {
+	Kind Kind `json:"kind"`
+	// Level of a heading, from 1 to 6
+	Level int `json:"level,omitempty"`
+	// Text of a heading, paragraph, quote or social post, without formatting
+	Text string `json:"text,omitempty"`
+	// Links inside the text, the list items or the table cells
+	Links []Link `json:"links,omitempty"`
+	// URL of an image, of the embedded content or of the social post
+	URL string `json:"url,omitempty"`
+	// Caption of an image or embed, the name of the network of a social post
+	Caption string `json:"caption,omitempty"`
+	// Items of a list
+	Items   []string `json:"items,omitempty"`
+	Ordered bool     `json:"ordered,omitempty"`
+	// Rows of a table, the first one is the header
+	Rows [][]string `json:"rows,omitempty"`
+	// Images of a gallery, each one an Image block
+	Images []Block `json:"images,omitempty"`
+}
+
+// Link is a link inside the text of a block. Start and End are the byte offsets of
+// the linked text in Text, or in the Item-th list item or table cell, counting the
+// cells row by row.
+type Link struct {
+	Item  int    `json:"item,omitempty"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	URL   string `json:"url"`
+}
+
+// Video reports if the block is an embedded video player.
+func (b Block) Video() bool {
+	return b.Kind == Embed && IsVideo(b.URL)
+}
+
+// IsVideo reports if link points to a video player, like YouTube's.
+func IsVideo(link string) bool {
+	return videoLinkRegexp.MatchString(link)
+}
+
+// Blocks is a whole body, in reading order.
+type Blocks []Block
+
+// Options change how Parse reads a body.
+type Options struct {
+	// Resolve makes the links of images, embeds and posts absolute
+	Resolve func(link string) string
+	// Gallery reports if an element is an image gallery, like a carousel. Every
+	// image inside of it becomes part of a single Gallery block.
+	Gallery func(n *html.Node) bool
+}
+
+// Parse reads the blocks of the children of root. Text that isn't inside a block
+// element, like the one the site separates with <br>, becomes paragraphs. The
+// blockquotes of Twitter, Instagram and TikTok embeds become Social blocks.
+func Parse(root *html.Node, opts Options) Blocks {
+	if opts.Resolve == nil {
+		opts.Resolve = func(link string) string { return link }
+	}
+	if opts.Gallery == nil {
+		opts.Gallery = func(*html.Node) bool { return false }
+	}
+	p := &parser{resolve: opts.Resolve, gallery: opts.Gallery}
+	p.children(root)
+	p.flush()
+	return p.blocks
+}
+
+type parser struct {
+	resolve func(link string) string
+	gallery func(n *html.Node) bool
+	blocks  Blocks
+	// Text of the paragraph being read and the links in it, with offsets in the text
+	// before it's normalized
+	text  strings.Builder
+	links []Link
+	// flushes counts the paragraphs ended, a link that spans two isn't kept
+	flushes int
+}
+
+func (p *parser) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.node(c)
+	}
+}
+
+func (p *parser) node(n *html.Node) {
+	if n.Type == html.TextNode {
+		p.text.WriteString(n.Data)
+		return
+	}
+	if n.Type != html.ElementNode {
+		return
+	}
+	if p.gallery(n) {
+		p.add(p.galleryBlock(n))
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Template:
+	case atom.Br, atom.Hr:
+		p.flush()
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text, links := p.inline(n, 0)
+		p.add(Block{Kind: Heading, Level: int(n.Data[1] - '0'), Text: text, Links: links})
+	case atom.Blockquote:
+		if network := socialNetwork(n); network != "" {
+			p.add(p.socialBlock(n, network))
+			return
+		}
+		text, links := p.inline(n, 0)
+		p.add(Block{Kind: Quote, Text: text, Links: links})
+	case atom.Ul, atom.Ol:
+		list := Block{Kind: List, Ordered: n.DataAtom == atom.Ol}
+		for _, li := range childElements(n, atom.Li) {
+			if item, links := p.inline(li, len(list.Items)); item != "" {
+				list.Items = append(list.Items, item)
+				list.Links = append(list.Links, links...)
+			}
+		}
+		p.add(list)
+	case atom.Table:
+		table := Block{Kind: Table}
+		cells := 0
+		for _, tr := range findAll(n, atom.Tr) {
+			var row []string
+			for _, cell := range childElements(tr, atom.Th, atom.Td) {
+				text, links := p.inline(cell, cells)
+				row = append(row, text)
+				table.Links = append(table.Links, links...)
+				cells++
+			}
+			if len(row) > 0 {
+				table.Rows = append(table.Rows, row)
+			}
+		}
+		p.add(table)
+	case atom.Img:
+		if src := imageSource(n); src != "" {
+			p.add(Block{Kind: Image, URL: p.resolve(src), Caption: attr(n, "alt")})
+		}
+	case atom.Figure:
+		imgs := findAll(n, atom.Img)
+		if len(imgs) != 1 || imageSource(imgs[0]) == "" {
+			p.flush()
+			p.children(n)
+			p.flush()
+			return
+		}
+		caption := attr(imgs[0], "alt")
+		if figcaptions := findAll(n, atom.Figcaption); len(figcaptions) > 0 {
+			caption = text(figcaptions[0])
+		}
+		p.add(Block{Kind: Image, URL: p.resolve(imageSource(imgs[0])), Caption: caption})
+	case atom.Iframe, atom.Video, atom.Embed:
+		src := attr(n, "src")
+		if src == "" {
+			if sources := findAll(n, atom.Source); len(sources) > 0 {
+				src = attr(sources[0], "src")
+			}
+		}
+		if src != "" {
+			p.add(Block{Kind: Embed, URL: p.resolve(src), Caption: attr(n, "title")})
+		}
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer,
+		atom.Aside, atom.Main, atom.Center, atom.Figcaption, atom.Pre:
+		p.flush()
+		p.children(n)
+		p.flush()
+	case atom.A:
+		start, flushes := p.text.Len(), p.flushes
+		p.children(n)
+		if href := attr(n, "href"); linkable(href) && p.flushes == flushes {
+			p.links = append(p.links, Link{Start: start, End: p.text.Len(), URL: p.resolve(href)})
+		}
+	default:
+		// Inline elements, like bold text, are part of the paragraph
+		p.children(n)
+	}
+}
+
+// inline returns the text of the node and its descendants with the links in it, as
+// the item-th item of the block.
+func (p *parser) inline(n *html.Node, item int) (string, []Link) {
+	var b strings.Builder
+	var links []Link
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style):
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			b.WriteString(" ")
+		default:
+			start := b.Len()
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+			if href := attr(n, "href"); n.DataAtom == atom.A && linkable(href) {
+				links = append(links, Link{Item: item, Start: start, End: b.Len(), URL: p.resolve(href)})
+			}
+		}
+	}
+	walk(n)
+	return normalizeLinks(b.String(), links)
+}
+
+// linkable reports if href is worth keeping as a link, anchors and scripts aren't.
+func linkable(href string) bool {
+	return href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(strings.ToLower(href), "javascript:")
+}
+
+// add ends the paragraph being read and adds the block after it, empty blocks are dropped.
+func (p *parser) add(block Block) {
+	p.flush()
+	if block.Text == "" && block.URL == "" && len(block.Items) == 0 && len(block.Rows) == 0 && len(block.Images) == 0 {
+		return
+	}
+	p.blocks = append(p.blocks, block)
+}
+
+// galleryBlock returns the images of the gallery, with the text around each one as
+// its caption. Carousels clone their first and last items, repeated images are dropped.
+func (p *parser) galleryBlock(n *html.Node) Block {
+	gallery := Block{Kind: Gallery}
+	seen := map[string]bool{}
+	for _, img := range findAll(n, atom.Img) {
+		src := imageSource(img)
+		if src == "" || seen[p.resolve(src)] {
+			continue
+		}
+		seen[p.resolve(src)] = true
+		gallery.Images = append(gallery.Images, Block{Kind: Image, URL: p.resolve(src), Caption: galleryCaption(n, img)})
+	}
+	return gallery
+}
+
+// galleryCaption returns the caption of an image of a gallery, the text of the
+// closest item around it or its alt.
+func galleryCaption(gallery, img *html.Node) string {
+	for item := img.Parent; item != nil && item != gallery; item = item.Parent {
+		if len(findAll(item, atom.Img)) > 1 {
+			break
+		}
+		if caption := text(item); caption != "" {
+			return caption
+		}
+	}
+	if alt := attr(img, "alt"); alt != "" {
+		return alt
+	}
+	return attr(img, "title")
+}
+
+// socialBlock returns the post of the embed. Without the script of the network the
+// blockquote has the text of the post and a link to it.
+func (p *parser) socialBlock(n *html.Node, network string) Block {
+	post := Block{Kind: Social, Caption: network}
+	for _, key := range []string{"data-instgrm-permalink", "cite"} {
+		if link := attr(n, key); link != "" {
+			post.URL = p.resolve(link)
+			break
+		}
+	}
+	// Tweets end with the link to the post, after the name of the author
+	if links := findAll(n, atom.A); post.URL == "" && len(links) > 0 {
+		post.URL = p.resolve(attr(links[len(links)-1], "href"))
+	}
+
+	var paragraphs []string
+	for _, paragraph := range findAll(n, atom.P) {
+		if t := text(paragraph); t != "" {
+			paragraphs = append(paragraphs, t)
+		}
+	}
+	post.Text = strings.Join(paragraphs, " ")
+	if post.Text == "" {
+		post.Text = text(n)
+	}
+	return post
+}
+
+// socialNetwork returns the name of the network of an embedded post, empty when the
+// blockquote is a quote.
+func socialNetwork(n *html.Node) string {
+	classes := strings.Fields(attr(n, "class"))
+	for _, network := range socialNetworks {
+		for _, class := range classes {
+			if class == network.class {
+				return network.name
+			}
+		}
+	}
+	return ""
+}
+
+// provider returns the name of the site a link points to, like "YouTube".
+func provider(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	switch {
+	case strings.HasSuffix(host, "youtube.com"), host == "youtu.be":
+		return "YouTube"
+	case strings.HasSuffix(host, "vimeo.com"):
+		return "Vimeo"
+	case strings.HasSuffix(host, "facebook.com"):
+		return "Facebook"
+	}
+	return host
+}
+
+func (p *parser) flush() {
+	if text, links := normalizeLinks(p.text.String(), p.links); text != "" {
+		p.blocks = append(p.blocks, Block{Kind: Paragraph, Text: text, Links: links})
+	}
+	p.text.Reset()
+	p.links = nil
+	p.flushes++
+}
+
+// text returns the text of the node and its descendants, with the spacing normalized.
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style):
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			b.WriteString(" ")
+		default:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return normalize(b.String())
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// normalizeLinks is like normalize but also moves the offsets of the links to the
+// normalized text. Links left without text are dropped.
+func normalizeLinks(s string, links []Link) (string, []Link) {
+	var b strings.Builder
+	// at is the offset in the normalized text of each byte of s
+	at := make([]int, len(s)+1)
+	space := false
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if unicode.IsSpace(r) {
+			space = b.Len() > 0
+		} else {
+			if space {
+				b.WriteByte(' ')
+				space = false
+			}
+			b.WriteString(s[i : i+size])
+		}
+		for j := i; j < i+size; j++ {
+			at[j+1] = b.Len()
+		}
+		i += size
+	}
+	text := b.String()
+
+	var normalized []Link
+	for _, link := range links {
+		// A link that starts with a space starts after it
+		start, end := at[link.Start], at[link.End]
+		for start < end && text[start] == ' ' {
+			start++
+		}
+		if start < end {
+			normalized = append(normalized, Link{Item: link.Item, Start: start, End: end, URL: link.URL})
+		}
+	}
+	return text, normalized
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+// imageSource returns the source of the image, the ones that are loaded lazily have
+// a placeholder as src and the real one in data-src.
+func imageSource(img *html.Node) string {
+	for _, key := range []string{"data-src", "src"} {
+		if src := attr(img, key); src != "" && !strings.HasPrefix(src, "data:") {
+			return src
+		}
+	}
+	return ""
+}
+
+// childElements returns the children of n that are one of the given elements.
+func childElements(n *html.Node, elements ...atom.Atom) []*html.Node {
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		for _, element := range elements {
+			if c.Type == html.ElementNode && c.DataAtom == element {
+				children = append(children, c)
+			}
+		}
+	}
+	return children
+}
+
+// findAll returns the descendants of n that are the given element, in document order.
+func findAll(n *html.Node, element atom.Atom) []*html.Node {
+	var found []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == element {
+			found = append(found, c)
+		}
+		found = append(found, findAll(c, element)...)
+	}
+	return found
+}
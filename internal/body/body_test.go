@@ -0,0 +1,230 @@
+package body
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// parse parses the fragment as the children of a div.
+func parse(t *testing.T, fragment string) Blocks {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader("<div id=root>" + fragment + "</div>"))
+	if err != nil {
+		t.Fatalf("parsing the fragment: %v", err)
+	}
+	var root *html.Node
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		if attr(n, "id") == "root" {
+			root = n
+		}
+		for c := n.FirstChild; c != nil && root == nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+	find(doc)
+	return Parse(root, Options{
+		Resolve: func(link string) string {
+			if strings.HasPrefix(link, "/") {
+				return "https://example.com" + link
+			}
+			return link
+		},
+		Gallery: func(n *html.Node) bool {
+			return strings.Contains(attr(n, "class"), "carousel")
+		},
+	})
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		want     Blocks
+	}{
+		{
+			"paragraphs",
+			"<p>Primer <strong>párrafo</strong>.</p>\n<p>  Segundo\n párrafo </p><p> </p>",
+			Blocks{{Kind: Paragraph, Text: "Primer párrafo."}, {Kind: Paragraph, Text: "Segundo párrafo"}},
+		},
+		{
+			"text separated by line breaks",
+			"Primera línea<br><br>Segunda <a href='/x'>línea</a><br>",
+			Blocks{
+				{Kind: Paragraph, Text: "Primera línea"},
+				{Kind: Paragraph, Text: "Segunda línea", Links: []Link{{Start: 8, End: 14, URL: "https://example.com/x"}}},
+			},
+		},
+		{
+			"links",
+			`<p>Según <a href="/informe"> el <b>informe</b> </a> del <a href="#nota">municipio</a>.</p>` +
+				`<blockquote>Dijo <a href="https://example.org">alguien</a></blockquote>` +
+				`<ul><li>Uno</li><li>Ver <a href="/dos">dos</a></li></ul>` +
+				`<table><tr><th>Sitio</th></tr><tr><td><a href="/tres">Tres</a></td></tr></table>`,
+			Blocks{
+				{Kind: Paragraph, Text: "Según el informe del municipio.", Links: []Link{{Start: 7, End: 17, URL: "https://example.com/informe"}}},
+				{Kind: Quote, Text: "Dijo alguien", Links: []Link{{Start: 5, End: 12, URL: "https://example.org"}}},
+				{Kind: List, Items: []string{"Uno", "Ver dos"}, Links: []Link{{Item: 1, Start: 4, End: 7, URL: "https://example.com/dos"}}},
+				{Kind: Table, Rows: [][]string{{"Sitio"}, {"Tres"}}, Links: []Link{{Item: 1, Start: 0, End: 4, URL: "https://example.com/tres"}}},
+			},
+		},
+		{
+			"headings and quotes",
+			"<h2>Subtítulo</h2><blockquote><p>Una cita</p></blockquote>",
+			Blocks{{Kind: Heading, Level: 2, Text: "Subtítulo"}, {Kind: Quote, Text: "Una cita"}},
+		},
+		{
+			"images",
+			`<p>Antes <img src="/a.jpg" alt="A"> después</p><figure><img data-src="/b.jpg" src="data:image/gif;base64,"><figcaption>Foto B</figcaption></figure>`,
+			Blocks{
+				{Kind: Paragraph, Text: "Antes"},
+				{Kind: Image, URL: "https://example.com/a.jpg", Caption: "A"},
+				{Kind: Paragraph, Text: "después"},
+				{Kind: Image, URL: "https://example.com/b.jpg", Caption: "Foto B"},
+			},
+		},
+		{
+			"embeds",
+			`<iframe src="https://www.youtube.com/embed/x" title="Video"></iframe><video><source src="/v.mp4"></video>`,
+			Blocks{
+				{Kind: Embed, URL: "https://www.youtube.com/embed/x", Caption: "Video"},
+				{Kind: Embed, URL: "https://example.com/v.mp4"},
+			},
+		},
+		{
+			"galleries",
+			`<div class="carousel"><div class="item"><img src="/1.jpg"><p>Primera</p></div><div class="item cloned"><img src="/1.jpg"></div><img src="/2.jpg" alt="Segunda"></div>`,
+			Blocks{{Kind: Gallery, Images: []Block{
+				{Kind: Image, URL: "https://example.com/1.jpg", Caption: "Primera"},
+				{Kind: Image, URL: "https://example.com/2.jpg", Caption: "Segunda"},
+			}}},
+		},
+		{
+			"social posts",
+			`<blockquote class="twitter-tweet"><p lang="es">Hoy hay <a href="https://t.co/x">feria</a></p>&mdash; Municipio (@muni) <a href="https://twitter.com/muni/status/1">15 de enero</a></blockquote>` +
+				`<blockquote class="instagram-media" data-instgrm-permalink="https://www.instagram.com/p/abc/"><a href="https://www.instagram.com/p/abc/">Ver esta publicación en Instagram</a></blockquote>`,
+			Blocks{
+				{Kind: Social, Caption: "Twitter", Text: "Hoy hay feria", URL: "https://twitter.com/muni/status/1"},
+				{Kind: Social, Caption: "Instagram", Text: "Ver esta publicación en Instagram", URL: "https://www.instagram.com/p/abc/"},
+			},
+		},
+		{
+			"lists",
+			"<ul><li>Uno</li><li> Dos </li><li></li></ul><ol><li>Primero</li></ol>",
+			Blocks{
+				{Kind: List, Items: []string{"Uno", "Dos"}},
+				{Kind: List, Items: []string{"Primero"}, Ordered: true},
+			},
+		},
+		{
+			"tables",
+			"<table><thead><tr><th>Día</th><th>Hora</th></tr></thead><tbody><tr><td>Lunes</td><td>10</td></tr></tbody></table>",
+			Blocks{{Kind: Table, Rows: [][]string{{"Día", "Hora"}, {"Lunes", "10"}}}},
+		},
+		{
+			"scripts are ignored",
+			"<p>Texto</p><script>alert(1)</script><style>p {}</style>",
+			Blocks{{Kind: Paragraph, Text: "Texto"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parse(t, tt.fragment); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+var blocks = Blocks{
+	{Kind: Heading, Level: 2, Text: "Subtítulo"},
+	{Kind: Paragraph, Text: "1. no es una lista"},
+	{Kind: Paragraph, Text: "Ver *el* informe [PDF] #1 de `hoy`", Links: []Link{{Start: 4, End: 16, URL: "https://example.com/a (1).pdf"}}},
+	{Kind: Quote, Text: "Una cita"},
+	{Kind: Image, URL: "https://example.com/a.jpg", Caption: "Foto [archivo]"},
+	{Kind: Embed, URL: "https://www.youtube.com/embed/x"},
+	{Kind: Embed, URL: "https://maps.example.com/x", Caption: "Mapa"},
+	{Kind: List, Items: []string{"Uno", "Dos"}, Ordered: true, Links: []Link{{Item: 1, Start: 0, End: 3, URL: "https://example.com/dos"}}},
+	{Kind: Table, Rows: [][]string{{"Día", "Hora"}, {"Lunes", "10 | 11"}}},
+	{Kind: Gallery, Images: []Block{{Kind: Image, URL: "https://example.com/1.jpg", Caption: "Uno"}, {Kind: Image, URL: "https://example.com/2.jpg"}}},
+	{Kind: Social, Caption: "Twitter", Text: "Hoy hay feria", URL: "https://twitter.com/muni/status/1"},
+}
+
+func TestMarkdown(t *testing.T) {
+	want := strings.Join([]string{
+		"## Subtítulo",
+		`1\. no es una lista`,
+		"Ver [\\*el\\* informe](https://example.com/a%20%281%29.pdf) \\[PDF\\] \\#1 de \\`hoy\\`",
+		"> Una cita",
+		`![Foto \[archivo\]](https://example.com/a.jpg)`,
+		"[▶ Video: YouTube](https://www.youtube.com/embed/x)",
+		"[↗ Contenido embebido: Mapa](https://maps.example.com/x)",
+		"1. Uno\n2. [Dos](https://example.com/dos)",
+		"| Día | Hora |\n| --- | --- |\n| Lunes | 10 \\| 11 |",
+		"**🖼 Galería de 2 imágenes**\n\n- ![Uno](https://example.com/1.jpg)\n- ![Imagen 2](https://example.com/2.jpg)",
+		"> **Publicación de Twitter**\n>\n> Hoy hay feria\n>\n> [Ver en Twitter](https://twitter.com/muni/status/1)",
+	}, "\n\n")
+	if got := blocks.Markdown(); got != want {
+		t.Errorf("Markdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestText(t *testing.T) {
+	want := strings.Join([]string{
+		"Subtítulo",
+		"1. no es una lista",
+		"Ver *el* informe (https://example.com/a (1).pdf) [PDF] #1 de `hoy`",
+		"“Una cita”",
+		"Foto [archivo] (https://example.com/a.jpg)",
+		"▶ Video: YouTube (https://www.youtube.com/embed/x)",
+		"↗ Contenido embebido: Mapa (https://maps.example.com/x)",
+		"1. Uno\n2. Dos (https://example.com/dos)",
+		"Día | Hora\nLunes | 10 | 11",
+		"🖼 Galería de 2 imágenes:\n- Uno (https://example.com/1.jpg)\n- Imagen 2 (https://example.com/2.jpg)",
+		"Publicación de Twitter: “Hoy hay feria” (https://twitter.com/muni/status/1)",
+	}, "\n\n")
+	if got := blocks.Text(); got != want {
+		t.Errorf("Text =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	got := Blocks{
+		{Kind: Paragraph, Text: "<b>no</b> & sí"},
+		{Kind: Image, URL: "https://example.com/a.jpg?x=1&y=2", Caption: "Foto"},
+		{Kind: List, Items: []string{"Uno <a>"}, Links: []Link{{Start: 0, End: 3, URL: "https://example.com/?a=1&b=2"}}},
+		{Kind: Embed, URL: "https://youtu.be/x"},
+		{Kind: Gallery, Images: []Block{{Kind: Image, URL: "https://example.com/1.jpg"}}},
+		{Kind: Social, Caption: "Instagram", Text: "<3", URL: "https://www.instagram.com/p/abc/"},
+	}.HTML()
+	want := "<p>&lt;b&gt;no&lt;/b&gt; &amp; sí</p>\n" +
+		`<figure><img src="https://example.com/a.jpg?x=1&amp;y=2" alt="Foto"><figcaption>Foto</figcaption></figure>` + "\n" +
+		`<ul><li><a href="https://example.com/?a=1&amp;b=2">Uno</a> &lt;a&gt;</li></ul>` + "\n" +
+		`<p><a href="https://youtu.be/x">▶ Video: YouTube</a></p>` + "\n" +
+		`<div class="gallery"><figure><img src="https://example.com/1.jpg" alt=""></figure></div>` + "\n" +
+		`<blockquote class="social"><p>&lt;3</p><footer><a href="https://www.instagram.com/p/abc/">Ver en Instagram</a></footer></blockquote>` + "\n"
+	if got != want {
+		t.Errorf("HTML =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var got Blocks
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(got, blocks) {
+		t.Errorf("round trip = %+v, want %+v", got, blocks)
+	}
+	if !strings.Contains(string(data), `{"kind":"heading","level":2,"text":"Subtítulo"}`) {
+		t.Errorf("JSON = %s, want the fields of the heading only", data)
+	}
+}
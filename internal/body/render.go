@@ -0,0 +1,355 @@
+package body
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Markdown renders the body as CommonMark, with GitHub's syntax for the tables.
+func (b Blocks) Markdown() string {
+	return b.join(Block.markdown)
+}
+
+// Text renders the body as plain text, links to images and embeds are kept.
+func (b Blocks) Text() string {
+	return b.join(Block.plainText)
+}
+
+// HTML renders the body as an HTML fragment, embeds become links to their content.
+// Galleries are wrapped in a div and social posts in a blockquote, with the class
+// "gallery" and "social" so they can be styled.
+func (b Blocks) HTML() string {
+	var s strings.Builder
+	for _, block := range b {
+		s.WriteString(block.html())
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+func (b Blocks) join(render func(Block) string) string {
+	parts := make([]string, 0, len(b))
+	for _, block := range b {
+		if part := render(block); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func (b Block) markdown() string {
+	switch b.Kind {
+	case Heading:
+		return strings.Repeat("#", b.level()) + " " + b.inlineMarkdown(0, b.Text)
+	case Paragraph:
+		return escapeLineStart(b.inlineMarkdown(0, b.Text))
+	case Quote:
+		return "> " + escapeLineStart(b.inlineMarkdown(0, b.Text))
+	case Image:
+		return fmt.Sprintf("![%s](%s)", EscapeMarkdown(b.Caption), markdownURL(b.URL))
+	case Embed:
+		return fmt.Sprintf("[%s](%s)", EscapeMarkdown(b.label()), markdownURL(b.URL))
+	case List:
+		lines := make([]string, len(b.Items))
+		for i, item := range b.Items {
+			lines[i] = b.marker(i) + " " + escapeLineStart(b.inlineMarkdown(i, item))
+		}
+		return strings.Join(lines, "\n")
+	case Gallery:
+		if len(b.Images) == 0 {
+			return ""
+		}
+		lines := []string{"**" + b.galleryTitle() + "**", ""}
+		for i, image := range b.Images {
+			lines = append(lines, fmt.Sprintf("- ![%s](%s)", EscapeMarkdown(image.galleryCaption(i)), markdownURL(image.URL)))
+		}
+		return strings.Join(lines, "\n")
+	case Social:
+		network := EscapeMarkdown(b.Caption)
+		lines := []string{"> **Publicación de " + network + "**"}
+		if b.Text != "" {
+			lines = append(lines, ">", "> "+escapeLineStart(EscapeMarkdown(b.Text)))
+		}
+		if b.URL != "" {
+			lines = append(lines, ">", fmt.Sprintf("> [Ver en %s](%s)", network, markdownURL(b.URL)))
+		}
+		return strings.Join(lines, "\n")
+	case Table:
+		if len(b.Rows) == 0 {
+			return ""
+		}
+		columns := 0
+		for _, row := range b.Rows {
+			columns = max(columns, len(row))
+		}
+		separator := make([]string, columns)
+		for i := range separator {
+			separator[i] = "---"
+		}
+		cells := make([][]string, len(b.Rows))
+		cell := 0
+		for i, row := range b.Rows {
+			cells[i] = make([]string, len(row))
+			for j, text := range row {
+				cells[i][j] = b.inlineMarkdown(cell, text)
+				cell++
+			}
+		}
+		lines := []string{tableRow(cells[0], columns), "| " + strings.Join(separator, " | ") + " |"}
+		for _, row := range cells[1:] {
+			lines = append(lines, tableRow(row, columns))
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
+
+func (b Block) plainText() string {
+	switch b.Kind {
+	case Heading, Paragraph:
+		return b.inlineText(0, b.Text)
+	case Quote:
+		return "“" + b.inlineText(0, b.Text) + "”"
+	case Image:
+		if b.Caption == "" {
+			return b.URL
+		}
+		return b.Caption + " (" + b.URL + ")"
+	case Embed:
+		return b.label() + " (" + b.URL + ")"
+	case Gallery:
+		if len(b.Images) == 0 {
+			return ""
+		}
+		lines := []string{b.galleryTitle() + ":"}
+		for i, image := range b.Images {
+			lines = append(lines, "- "+image.galleryCaption(i)+" ("+image.URL+")")
+		}
+		return strings.Join(lines, "\n")
+	case Social:
+		post := "Publicación de " + b.Caption + ":"
+		if b.Text != "" {
+			post += " “" + b.Text + "”"
+		}
+		if b.URL != "" {
+			post += " (" + b.URL + ")"
+		}
+		return post
+	case List:
+		lines := make([]string, len(b.Items))
+		for i, item := range b.Items {
+			lines[i] = b.marker(i) + " " + b.inlineText(i, item)
+		}
+		return strings.Join(lines, "\n")
+	case Table:
+		lines := make([]string, len(b.Rows))
+		cell := 0
+		for i, row := range b.Rows {
+			texts := make([]string, len(row))
+			for j, text := range row {
+				texts[j] = b.inlineText(cell, text)
+				cell++
+			}
+			lines[i] = strings.Join(texts, " | ")
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
+
+func (b Block) html() string {
+	e := html.EscapeString
+	switch b.Kind {
+	case Heading:
+		return fmt.Sprintf("<h%d>%s</h%d>", b.level(), b.inlineHTML(0, b.Text), b.level())
+	case Paragraph:
+		return "<p>" + b.inlineHTML(0, b.Text) + "</p>"
+	case Quote:
+		return "<blockquote>" + b.inlineHTML(0, b.Text) + "</blockquote>"
+	case Image:
+		img := fmt.Sprintf(`<img src="%s" alt="%s">`, e(b.URL), e(b.Caption))
+		if b.Caption == "" {
+			return "<figure>" + img + "</figure>"
+		}
+		return "<figure>" + img + "<figcaption>" + e(b.Caption) + "</figcaption></figure>"
+	case Embed:
+		return fmt.Sprintf(`<p><a href="%s">%s</a></p>`, e(b.URL), e(b.label()))
+	case List:
+		tag := "ul"
+		if b.Ordered {
+			tag = "ol"
+		}
+		var s strings.Builder
+		s.WriteString("<" + tag + ">")
+		for i, item := range b.Items {
+			s.WriteString("<li>" + b.inlineHTML(i, item) + "</li>")
+		}
+		s.WriteString("</" + tag + ">")
+		return s.String()
+	case Gallery:
+		if len(b.Images) == 0 {
+			return ""
+		}
+		var s strings.Builder
+		s.WriteString(`<div class="gallery">`)
+		for _, image := range b.Images {
+			s.WriteString(image.html())
+		}
+		s.WriteString("</div>")
+		return s.String()
+	case Social:
+		var s strings.Builder
+		s.WriteString(`<blockquote class="social">`)
+		if b.Text != "" {
+			s.WriteString("<p>" + e(b.Text) + "</p>")
+		}
+		if b.URL != "" {
+			s.WriteString(fmt.Sprintf(`<footer><a href="%s">Ver en %s</a></footer>`, e(b.URL), e(b.Caption)))
+		}
+		s.WriteString("</blockquote>")
+		return s.String()
+	case Table:
+		if len(b.Rows) == 0 {
+			return ""
+		}
+		var s strings.Builder
+		s.WriteString("<table><thead><tr>")
+		for i, cell := range b.Rows[0] {
+			s.WriteString("<th>" + b.inlineHTML(i, cell) + "</th>")
+		}
+		s.WriteString("</tr></thead><tbody>")
+		cells := len(b.Rows[0])
+		for _, row := range b.Rows[1:] {
+			s.WriteString("<tr>")
+			for _, cell := range row {
+				s.WriteString("<td>" + b.inlineHTML(cells, cell) + "</td>")
+				cells++
+			}
+			s.WriteString("</tr>")
+		}
+		s.WriteString("</tbody></table>")
+		return s.String()
+	}
+	return ""
+}
+
+// inline renders the item-th text of the block, rendering the text around the links
+// with text and each link with link.
+func (b Block) inline(item int, s string, text func(string) string, link func(text, url string) string) string {
+	var out strings.Builder
+	end := 0
+	for _, l := range b.Links {
+		// Links out of the text, or overlapping the one before, are ignored
+		if l.Item != item || l.Start < end || l.End > len(s) || l.Start >= l.End {
+			continue
+		}
+		out.WriteString(text(s[end:l.Start]))
+		out.WriteString(link(s[l.Start:l.End], l.URL))
+		end = l.End
+	}
+	out.WriteString(text(s[end:]))
+	return out.String()
+}
+
+func (b Block) inlineMarkdown(item int, s string) string {
+	return b.inline(item, s, EscapeMarkdown, func(text, url string) string {
+		return "[" + EscapeMarkdown(text) + "](" + markdownURL(url) + ")"
+	})
+}
+
+func (b Block) inlineText(item int, s string) string {
+	return b.inline(item, s, func(text string) string { return text }, func(text, url string) string {
+		return text + " (" + url + ")"
+	})
+}
+
+func (b Block) inlineHTML(item int, s string) string {
+	return b.inline(item, s, html.EscapeString, func(text, url string) string {
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), html.EscapeString(text))
+	})
+}
+
+func (b Block) level() int {
+	return min(max(b.Level, 1), 6)
+}
+
+// label is the text of the link to an embed, it says what the embed is so readers
+// know there's content they can't see, like "▶ Video: YouTube".
+func (b Block) label() string {
+	name := b.Caption
+	if name == "" {
+		name = provider(b.URL)
+	}
+	if name == "" {
+		name = b.URL
+	}
+	if b.Video() {
+		return "▶ Video: " + name
+	}
+	return "↗ Contenido embebido: " + name
+}
+
+func (b Block) galleryTitle() string {
+	if len(b.Images) == 1 {
+		return "🖼 Galería de 1 imagen"
+	}
+	return fmt.Sprintf("🖼 Galería de %d imágenes", len(b.Images))
+}
+
+// galleryCaption is the caption of the i-th image of a gallery, its number when it has none.
+func (b Block) galleryCaption(i int) string {
+	if b.Caption != "" {
+		return b.Caption
+	}
+	return fmt.Sprintf("Imagen %d", i+1)
+}
+
+func (b Block) marker(i int) string {
+	if b.Ordered {
+		return fmt.Sprintf("%d.", i+1)
+	}
+	return "-"
+}
+
+func tableRow(cells []string, columns int) string {
+	row := make([]string, columns)
+	for i := range row {
+		if i < len(cells) {
+			row[i] = strings.ReplaceAll(cells[i], "|", `\|`)
+		}
+	}
+	return "| " + strings.Join(row, " | ") + " |"
+}
+
+// markdownEscaper escapes the characters Markdown reads as inline formatting, like
+// emphasis, code, links and HTML, and the # that would close a heading.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`, "~", `\~`, "#", `\#`,
+)
+
+// EscapeMarkdown escapes the text so Markdown shows it as it is, wherever it's written.
+func EscapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// markdownURL escapes the characters that would end the destination of a link.
+func markdownURL(link string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace(link)
+}
+
+// escapeLineStart escapes what would make the start of the text a heading, a list or a quote.
+func escapeLineStart(text string) string {
+	if text == "" {
+		return text
+	}
+	switch text[0] {
+	case '#', '-', '+', '*', '>', '=':
+		return `\` + text
+	}
+	// Ordered lists, like "1. "
+	if i := strings.IndexFunc(text, func(r rune) bool { return r < '0' || r > '9' }); i > 0 && i+1 < len(text) && (text[i] == '.' || text[i] == ')') && text[i+1] == ' ' {
+		return text[:i] + `\` + text[i:]
+	}
+	return text
+}
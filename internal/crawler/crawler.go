@@ -0,0 +1,197 @@
+/*
+	Package crawler walks the paginated listing of a source and stores every article
+	into the archive, to backfill the history that's no longer on the front pages.
+*/
+
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"qpc-tui/internal/archive"
+	"qpc-tui/internal/scraper"
+)
+
+type Options struct {
+	FromPage int
+	ToPage   int
+	// Delay is how long to wait between pages, so we don't hammer the site
+	Delay time.Duration
+	// StatePath is where the progress is saved, so an interrupted crawl can be resumed
+	StatePath string
+}
+
+// state is what's saved to Options.StatePath after every page.
+type state struct {
+	FromPage int `json:"from_page"`
+	ToPage   int `json:"to_page"`
+	NextPage int `json:"next_page"`
+	// Failed are the links of the articles of the crawled pages that couldn't be
+	// loaded, they're retried at the end of the crawl and by the next one
+	Failed []string `json:"failed,omitempty"`
+}
+
+// Progress is reported after every crawled page, and after retrying the articles that failed.
+type Progress struct {
+	Page     int
+	ToPage   int
+	Articles int
+	Failed   int
+	Archived int
+	// Retried is set on the progress of the retry, Articles are the ones loaded
+	// this time and Failed the ones that still fail
+	Retried bool
+}
+
+/*
+	Run crawls the pages between opts.FromPage and opts.ToPage, stopping early when the
+	listing has no next page. If a previous crawl of the same range was interrupted it
+	continues from the first page that wasn't stored.
+
+	The articles that fail to load are retried once every page is crawled. The ones
+	that still fail are kept in the state, so running the same crawl again retries them.
+*/
+func Run(ctx context.Context, source scraper.Source, store *archive.Archive, opts Options, progress func(Progress)) error {
+	if opts.ToPage < opts.FromPage {
+		return errors.New("the last page must not be lower than the first one")
+	}
+
+	current := state{FromPage: opts.FromPage, ToPage: opts.ToPage, NextPage: opts.FromPage}
+	if s, err := loadState(opts.StatePath); err != nil {
+		return err
+	} else if s != nil && s.FromPage == opts.FromPage && s.ToPage == opts.ToPage {
+		current = *s
+		log.Info("Resuming crawl", "page", current.NextPage, "failed", len(current.Failed))
+	}
+
+	for page := current.NextPage; page <= opts.ToPage; page++ {
+		p, err := source.ListPage(ctx, page)
+		if err != nil {
+			return err
+		}
+
+		for _, article := range p.Articles {
+			if err := store.Put(article); err != nil {
+				return err
+			}
+		}
+		for _, failure := range p.Failures {
+			if retryable(failure.Err) && !slices.Contains(current.Failed, failure.Link) {
+				current.Failed = append(current.Failed, failure.Link)
+			}
+		}
+
+		current.NextPage = page + 1
+		if !p.CanContinue {
+			// Nothing left to crawl, a resumed crawl only retries the failed articles
+			current.NextPage = opts.ToPage + 1
+		}
+		if err := saveState(opts.StatePath, current); err != nil {
+			return err
+		}
+
+		if progress != nil {
+			progress(Progress{Page: page, ToPage: opts.ToPage, Articles: len(p.Articles), Failed: len(p.Failures), Archived: store.Len()})
+		}
+
+		if !p.CanContinue {
+			log.Info("Reached the last page of the listing", "page", page)
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(opts.Delay):
+		}
+	}
+
+	if len(current.Failed) > 0 {
+		if err := retryFailed(ctx, source, store, &current, opts.StatePath, progress); err != nil {
+			return err
+		}
+		if len(current.Failed) > 0 {
+			log.Warn("Some articles still fail to load, run the same crawl again to retry them", "failed", len(current.Failed))
+			return nil
+		}
+	}
+
+	// The crawl finished, the next one with the same range starts from scratch
+	if err := os.Remove(opts.StatePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// retryFailed fetches again the articles of s that failed, leaving in it the ones
+// that still fail.
+func retryFailed(ctx context.Context, source scraper.Source, store *archive.Archive, s *state, path string, progress func(Progress)) error {
+	var failed []string
+	loaded := 0
+	for _, link := range s.Failed {
+		article, err := source.FetchArticle(ctx, link)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			log.Warn("Could not load article again", "link", link, "error", err)
+			if retryable(err) {
+				failed = append(failed, link)
+			}
+			continue
+		}
+		if err := store.Put(*article); err != nil {
+			return err
+		}
+		loaded++
+	}
+	s.Failed = failed
+	if err := saveState(path, *s); err != nil {
+		return err
+	}
+	if progress != nil {
+		progress(Progress{ToPage: s.ToPage, Articles: loaded, Failed: len(failed), Archived: store.Len(), Retried: true})
+	}
+	return nil
+}
+
+// retryable reports if an article that failed to load may load when tried again,
+// pages that aren't articles never will.
+func retryable(err error) bool {
+	return !errors.Is(err, scraper.ErrArticleNotFound) && !errors.Is(err, scraper.ErrCategoryNotFound)
+}
+
+func loadState(path string) (*state, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// saveState writes the state to a temporary file first, so an interruption never leaves it half written.
+func saveState(path string, s state) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
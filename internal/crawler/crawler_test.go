@@ -0,0 +1,154 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"qpc-tui/internal/archive"
+	"qpc-tui/internal/scraper"
+)
+
+// fakeSource lists pages with one article each, up to lastPage. Page 2 also has an
+// article that fails while down is set, and a link that isn't an article.
+type fakeSource struct {
+	scraper.Source
+	lastPage int
+	down     bool
+	listed   []int
+}
+
+func (s *fakeSource) ListPage(ctx context.Context, page int) (*scraper.Page, error) {
+	s.listed = append(s.listed, page)
+	p := &scraper.Page{
+		Number:      page,
+		Articles:    []scraper.Article{article(fmt.Sprintf("/nota/%d", page))},
+		CanContinue: page < s.lastPage,
+	}
+	if page == 2 {
+		if _, err := s.FetchArticle(ctx, "/nota/caida"); err != nil {
+			p.Failures = append(p.Failures, scraper.ArticleError{Link: "/nota/caida", Err: err})
+		}
+		p.Failures = append(p.Failures, scraper.ArticleError{Link: "/contacto", Err: scraper.ErrArticleNotFound})
+	}
+	return p, nil
+}
+
+func (s *fakeSource) FetchArticle(ctx context.Context, link string) (*scraper.Article, error) {
+	if s.down {
+		return nil, errors.New("site down")
+	}
+	a := article(link)
+	return &a, nil
+}
+
+func article(link string) scraper.Article {
+	return scraper.Article{ID: link, Link: link, Title: link, Date: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)}
+}
+
+func setup(t *testing.T) (*archive.Archive, string) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := archive.Open(filepath.Join(dir, "archive.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, filepath.Join(dir, "archive.jsonl.crawl")
+}
+
+func TestRunStopsAtTheLastPage(t *testing.T) {
+	store, statePath := setup(t)
+	source := &fakeSource{lastPage: 3}
+
+	var crawled []int
+	err := Run(context.Background(), source, store, Options{FromPage: 1, ToPage: 10, StatePath: statePath}, func(p Progress) {
+		crawled = append(crawled, p.Page)
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !reflect.DeepEqual(source.listed, []int{1, 2, 3}) || !reflect.DeepEqual(crawled, []int{1, 2, 3}) {
+		t.Errorf("listed %v and reported %v, want pages 1 to 3", source.listed, crawled)
+	}
+	if store.Len() != 3 {
+		t.Errorf("archived %d articles, want the ones of the 3 pages", store.Len())
+	}
+	if _, err := os.Stat(statePath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the state is still there after the crawl finished: %v", err)
+	}
+}
+
+func TestRunResumes(t *testing.T) {
+	store, statePath := setup(t)
+	if err := saveState(statePath, state{FromPage: 1, ToPage: 4, NextPage: 3}); err != nil {
+		t.Fatal(err)
+	}
+	source := &fakeSource{lastPage: 10}
+
+	if err := Run(context.Background(), source, store, Options{FromPage: 1, ToPage: 4, StatePath: statePath}, nil); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !reflect.DeepEqual(source.listed, []int{3, 4}) {
+		t.Errorf("listed %v, want the pages after the saved one", source.listed)
+	}
+
+	// The state of another range is ignored
+	source.listed = nil
+	if err := saveState(statePath, state{FromPage: 1, ToPage: 9, NextPage: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(context.Background(), source, store, Options{FromPage: 1, ToPage: 2, StatePath: statePath}, nil); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !reflect.DeepEqual(source.listed, []int{1, 2}) {
+		t.Errorf("listed %v, want the whole range", source.listed)
+	}
+}
+
+func TestRunRetriesFailedArticles(t *testing.T) {
+	store, statePath := setup(t)
+	source := &fakeSource{lastPage: 3, down: true}
+	opts := Options{FromPage: 1, ToPage: 3, StatePath: statePath}
+
+	// The article that fails is kept in the state, the page that isn't an article isn't
+	if err := Run(context.Background(), source, store, opts, nil); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatalf("the state was removed with articles left to retry: %v", err)
+	}
+	var saved state
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.NextPage != 4 || !reflect.DeepEqual(saved.Failed, []string{"/nota/caida"}) {
+		t.Errorf("state = %+v, want every page crawled and the article that failed", saved)
+	}
+
+	// Running it again only retries that article
+	source.down, source.listed = false, nil
+	var progress []Progress
+	if err := Run(context.Background(), source, store, opts, func(p Progress) { progress = append(progress, p) }); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(source.listed) != 0 {
+		t.Errorf("listed %v, want no pages", source.listed)
+	}
+	if len(progress) != 1 || !progress[0].Retried || progress[0].Articles != 1 || progress[0].Failed != 0 {
+		t.Errorf("progress = %+v, want the retry of 1 article", progress)
+	}
+	if _, ok := store.Get("/nota/caida"); !ok {
+		t.Error("the article that failed wasn't archived")
+	}
+	if _, err := os.Stat(statePath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the state is still there after every article loaded: %v", err)
+	}
+}
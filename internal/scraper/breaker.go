@@ -0,0 +1,134 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// ErrCircuitOpen is returned instead of making a request while the site is considered down.
+var ErrCircuitOpen = errors.New("the site is not responding, requests are paused")
+
+// Breaker stops sending requests to the site once it failed several times in a row,
+// so the sessions don't keep hammering a site that is down. After the cooldown a
+// single request is let through to probe it, if it succeeds the requests resume,
+// otherwise the breaker waits for another cooldown.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// BreakerPolicy configures a Breaker.
+type BreakerPolicy struct {
+	// Failures in a row that open the breaker, 0 disables it
+	Failures int
+	// Cooldown is how long the breaker stays open before probing the site again
+	Cooldown time.Duration
+}
+
+var DefaultBreakerPolicy = BreakerPolicy{
+	Failures: 5,
+	Cooldown: 30 * time.Second,
+}
+
+func NewBreaker(policy BreakerPolicy) *Breaker {
+	return &Breaker{threshold: policy.Failures, cooldown: policy.Cooldown}
+}
+
+// Open reports if the requests are paused because the site is down. A nil Breaker,
+// the one of a source without it, is never open.
+func (b *Breaker) Open() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold
+}
+
+// allow reports if a request can be made now. While the breaker is open only the
+// probe is allowed once the cooldown is over.
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record counts the outcome of a request.
+func (b *Breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wasOpen := b.failures >= b.threshold
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		if wasOpen {
+			log.Info("The site is responding again, resuming the requests")
+		}
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+		if !wasOpen {
+			log.Warn("The site failed too many times in a row, pausing the requests", "failures", b.failures, "cooldown", b.cooldown)
+		}
+	}
+}
+
+// Reset closes the breaker, the site is known to respond again. It's a no-op on a nil Breaker.
+func (b *Breaker) Reset() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures >= b.threshold {
+		log.Info("The site is responding again, resuming the requests")
+	}
+	b.failures = 0
+	b.probing = false
+}
+
+// cancelled lets another request probe the site, the probe was cancelled by its caller.
+func (b *Breaker) cancelled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// breakerTransport fails fast with ErrCircuitOpen while the breaker is open. Network
+// errors and server errors count as failures, our own cancellations don't count at all.
+type breakerTransport struct {
+	breaker *Breaker
+	base    http.RoundTripper
+}
+
+func (t breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+	res, err := t.base.RoundTrip(req)
+	if errors.Is(err, context.Canceled) || req.Context().Err() != nil {
+		t.breaker.cancelled()
+		return res, err
+	}
+	t.breaker.record(err != nil || res.StatusCode >= 500)
+	return res, err
+}
@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Budget limits the requests made to a site by every collector that shares it, and so
+// by every session. At most concurrency requests are in flight at once and consecutive
+// requests start at least interval apart.
+type Budget struct {
+	slots    chan struct{}
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func NewBudget(concurrency int, interval time.Duration) *Budget {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Budget{slots: make(chan struct{}, concurrency), interval: interval}
+}
+
+// acquire waits for a free slot and for the request's turn, or until ctx is done.
+func (b *Budget) acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// Reserve the next start time, so waiting requests are spaced out instead of starting together
+	b.mu.Lock()
+	now := time.Now()
+	if b.next.Before(now) {
+		b.next = now
+	}
+	start := b.next
+	b.next = b.next.Add(b.interval)
+	b.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.release()
+		return ctx.Err()
+	}
+}
+
+func (b *Budget) release() {
+	<-b.slots
+}
+
+// releasingBody calls release once, when the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
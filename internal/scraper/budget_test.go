@@ -0,0 +1,107 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBudgetConcurrency(t *testing.T) {
+	b := NewBudget(2, 0)
+	for range 2 {
+		if err := b.acquire(context.Background()); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+	}
+
+	// Every slot is taken, so the third request waits
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := b.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire with every slot taken = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- b.acquire(context.Background())
+	}()
+	b.release()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("acquire after a release: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire still waiting after a release")
+	}
+}
+
+func TestBudgetInterval(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	b := NewBudget(3, interval)
+
+	var starts []time.Time
+	for range 3 {
+		if err := b.acquire(context.Background()); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		starts = append(starts, time.Now())
+		b.release()
+	}
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < interval-5*time.Millisecond {
+			t.Errorf("request %d started %v after the one before, want at least %v", i, gap, interval)
+		}
+	}
+}
+
+func TestBudgetCancel(t *testing.T) {
+	b := NewBudget(1, time.Hour)
+	if err := b.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	// Cancelled while waiting for a slot
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- b.acquire(ctx)
+	}()
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("acquire cancelled waiting for a slot = %v, want %v", err, context.Canceled)
+	}
+
+	// Cancelled while waiting for its turn, it gives the slot back
+	b.release()
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := b.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire cancelled waiting for its turn = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n := len(b.slots); n != 0 {
+		t.Errorf("%d slots taken after the cancelled requests, want 0", n)
+	}
+}
+
+func TestBudgetQueueTimeDoesNotCount(t *testing.T) {
+	// The articles wait in the budget longer than an attempt may take
+	q, _ := newTestSource(t, WithLimits(Limits{Parallelism: 4, Budget: NewBudget(1, 150*time.Millisecond)}), WithRetry(RetryPolicy{
+		Attempts: 1,
+		Timeout:  100 * time.Millisecond,
+	}))
+
+	p, err := q.ListPage(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("ListPage: %v", err)
+	}
+	if len(p.Articles) != 2 {
+		t.Errorf("got %d articles, want 2", len(p.Articles))
+	}
+	for _, failure := range p.Failures {
+		if errors.Is(failure.Err, context.DeadlineExceeded) {
+			t.Errorf("article %s timed out waiting for the budget: %v", failure.Link, failure.Err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package scraper
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+const (
+	// staleTTL is how long the pages and articles are kept after they expire, to be
+	// shown while the site is scraped again or while it's down
+	staleTTL = 24 * time.Hour
+	// revalidateTimeout bounds the scrapes that refresh an expired page in the background
+	revalidateTimeout = 2 * time.Minute
+)
+
+type cached[T any] struct {
+	value   T
+	expires time.Time
+	// invalidated entries are known to be outdated, they're only used if scraping them again fails
+	invalidated bool
+}
+
+/*
+	Cache is a Source that keeps the pages and articles scraped by another Source
+	for ttl, so every SSH session shares them instead of scraping the site again.
+	Concurrent requests for the same page or article wait for a single scrape.
+
+	Expired pages are returned right away marked as Stale while they're scraped again
+	in the background, the next request gets the new one. When scraping fails the
+	expired pages and articles are returned instead, for up to staleTTL.
+*/
+type Cache struct {
+	source Source
+	ttl    time.Duration
+
+	mu         sync.Mutex
+	pages      map[string]cached[*Page] // By pageKey
+	articles   map[string]cached[*Article]
+	categories cached[[]Category]
+
+	pageFlights     flightGroup[*Page]
+	articleFlights  flightGroup[*Article]
+	categoryFlights flightGroup[[]Category]
+}
+
+func NewCache(source Source, ttl time.Duration) *Cache {
+	return &Cache{
+		source:   source,
+		ttl:      ttl,
+		pages:    make(map[string]cached[*Page]),
+		articles: make(map[string]cached[*Article]),
+	}
+}
+
+func (c *Cache) Name() string {
+	return c.source.Name()
+}
+
+func (c *Cache) Categories(ctx context.Context) ([]Category, error) {
+	c.mu.Lock()
+	entry := c.categories
+	c.mu.Unlock()
+	if entry.value != nil && time.Now().Before(entry.expires) {
+		return append([]Category(nil), entry.value...), nil
+	}
+
+	categories, err := c.categoryFlights.Do(ctx, "", func(ctx context.Context) ([]Category, error) {
+		categories, err := c.source.Categories(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.categories = cached[[]Category]{value: categories, expires: time.Now().Add(c.ttl)}
+		c.mu.Unlock()
+		return categories, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]Category(nil), categories...), nil
+}
+
+func (c *Cache) ListPage(ctx context.Context, page int) (*Page, error) {
+	return c.listPage(ctx, pageKey(0, page), func(ctx context.Context) (*Page, error) {
+		return c.source.ListPage(ctx, page)
+	})
+}
+
+func (c *Cache) ListCategoryPage(ctx context.Context, category Category, page int) (*Page, error) {
+	return c.listPage(ctx, pageKey(category.Id, page), func(ctx context.Context) (*Page, error) {
+		return c.source.ListCategoryPage(ctx, category, page)
+	})
+}
+
+func (c *Cache) listPage(ctx context.Context, key string, scrape func(ctx context.Context) (*Page, error)) (*Page, error) {
+	c.mu.Lock()
+	entry, ok := c.pages[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return copyPage(entry.value), nil
+	}
+	if ok && !entry.invalidated {
+		go c.revalidate(key, scrape)
+		return stalePage(entry.value), nil
+	}
+
+	p, err := c.pageFlights.Do(ctx, key, c.scrapePage(key, scrape))
+	if err != nil {
+		if ok && ctx.Err() == nil {
+			log.Warn("Could not scrape the page, returning the stale one", "page", key, "error", err)
+			return stalePage(entry.value), nil
+		}
+		return nil, err
+	}
+	return copyPage(p), nil
+}
+
+// revalidate scrapes an expired page in the background, joining the scrape in flight if there's one.
+func (c *Cache) revalidate(key string, scrape func(ctx context.Context) (*Page, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), revalidateTimeout)
+	defer cancel()
+	if _, err := c.pageFlights.Do(ctx, key, c.scrapePage(key, scrape)); err != nil {
+		log.Warn("Could not revalidate the page", "page", key, "error", err)
+	}
+}
+
+// scrapePage returns the load of the page's flight, it stores the page once it's scraped.
+func (c *Cache) scrapePage(key string, scrape func(ctx context.Context) (*Page, error)) func(ctx context.Context) (*Page, error) {
+	return func(ctx context.Context) (*Page, error) {
+		p, err := scrape(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.storePage(key, p)
+		return p, nil
+	}
+}
+
+// pageKey identifies a page of the main listing, categoryId 0, or of a section's listing.
+func pageKey(categoryId, page int) string {
+	return strconv.Itoa(categoryId) + "/" + strconv.Itoa(page)
+}
+
+// ListLinks isn't cached, it's used to find out what changed on the site.
+func (c *Cache) ListLinks(ctx context.Context, page int) (*Page, error) {
+	return c.source.ListLinks(ctx, page)
+}
+
+func (c *Cache) FetchArticle(ctx context.Context, link string) (*Article, error) {
+	c.mu.Lock()
+	entry, ok := c.articles[link]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		article := *entry.value
+		return &article, nil
+	}
+
+	a, err := c.articleFlights.Do(ctx, link, func(ctx context.Context) (*Article, error) {
+		a, err := c.source.FetchArticle(ctx, link)
+		if err != nil {
+			return nil, err
+		}
+		c.storeArticle(a)
+		return a, nil
+	})
+	if err != nil {
+		if ok && ctx.Err() == nil {
+			log.Warn("Could not scrape the article, returning the stale one", "link", link, "error", err)
+			article := *entry.value
+			return &article, nil
+		}
+		return nil, err
+	}
+	article := *a
+	return &article, nil
+}
+
+// InvalidatePages makes every cached page be scraped again, new articles shift the
+// whole listing and the listing of their section. The old pages are only returned
+// if that fails.
+// Articles stay cached, they don't change when they move to another page.
+func (c *Cache) InvalidatePages() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.pages {
+		entry.invalidated = true
+		entry.expires = time.Now()
+		c.pages[key] = entry
+	}
+}
+
+func (c *Cache) storePage(key string, p *Page) {
+	expires := time.Now().Add(c.ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pages[key] = cached[*Page]{value: p, expires: expires}
+	// Articles of the page are cached too, so opening one doesn't scrape it again
+	for i := range p.Articles {
+		c.articles[p.Articles[i].Link] = cached[*Article]{value: &p.Articles[i], expires: expires}
+	}
+	c.evictExpired()
+}
+
+func (c *Cache) storeArticle(a *Article) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.articles[a.Link] = cached[*Article]{value: a, expires: time.Now().Add(c.ttl)}
+	c.evictExpired()
+}
+
+// evictExpired drops the entries that expired more than staleTTL ago so the cache
+// doesn't grow forever, c.mu must be held.
+func (c *Cache) evictExpired() {
+	now := time.Now()
+	for key, entry := range c.pages {
+		if now.After(entry.expires.Add(staleTTL)) {
+			delete(c.pages, key)
+		}
+	}
+	for link, entry := range c.articles {
+		if now.After(entry.expires.Add(staleTTL)) {
+			delete(c.articles, link)
+		}
+	}
+}
+
+// stalePage copies the page marking it as stale.
+func stalePage(p *Page) *Page {
+	cp := copyPage(p)
+	cp.Stale = true
+	return cp
+}
+
+// copyPage copies the page so callers can sort or modify the articles without affecting other sessions.
+func copyPage(p *Page) *Page {
+	cp := *p
+	cp.Articles = append([]Article(nil), p.Articles...)
+	cp.Failures = append([]ArticleError(nil), p.Failures...)
+	return &cp
+}
@@ -0,0 +1,89 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSource lists pages with a single article named after how many times it was scraped.
+type fakeSource struct {
+	Source
+	scrapes atomic.Int32
+	mu      sync.Mutex
+	err     error
+}
+
+func (s *fakeSource) ListPage(ctx context.Context, page int) (*Page, error) {
+	s.mu.Lock()
+	err := s.err
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	n := s.scrapes.Add(1)
+	return &Page{Number: page, Articles: []Article{{Title: string('0' + rune(n))}}, FetchedAt: time.Now()}, nil
+}
+
+func (s *fakeSource) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	source := &fakeSource{}
+	cache := NewCache(source, time.Millisecond)
+	ctx := context.Background()
+
+	if p, err := cache.ListPage(ctx, 0); err != nil || p.Stale || p.Articles[0].Title != "1" {
+		t.Fatalf("ListPage = %+v, %v, want the first scrape", p, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// The expired page is returned right away while it's scraped again
+	p, err := cache.ListPage(ctx, 0)
+	if err != nil || !p.Stale || p.Articles[0].Title != "1" {
+		t.Fatalf("ListPage = %+v, %v, want the first scrape marked as stale", p, err)
+	}
+	for deadline := time.Now().Add(time.Second); cachedTitle(cache, 0) != "2"; {
+		if time.Now().After(deadline) {
+			t.Fatal("the expired page wasn't scraped again")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cache.InvalidatePages()
+
+	// Invalidated pages are scraped before returning, the old one is only used if that fails
+	source.fail(errors.New("site down"))
+	p, err = cache.ListPage(ctx, 0)
+	if err != nil || !p.Stale || p.Articles[0].Title != "2" {
+		t.Fatalf("ListPage = %+v, %v, want the revalidated page marked as stale", p, err)
+	}
+
+	source.fail(nil)
+	p, err = cache.ListPage(ctx, 0)
+	if err != nil || p.Stale || p.Articles[0].Title != "3" {
+		t.Fatalf("ListPage = %+v, %v, want a new scrape", p, err)
+	}
+
+	// Pages never scraped have nothing to fall back to
+	source.fail(errors.New("site down"))
+	if _, err := cache.ListPage(ctx, 1); err == nil {
+		t.Error("ListPage of a page never scraped succeeded while the site is down")
+	}
+}
+
+// cachedTitle returns the title of the article of the cached page.
+func cachedTitle(c *Cache, page int) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.pages[pageKey(0, page)]
+	if !ok {
+		return ""
+	}
+	return entry.value.Articles[0].Title
+}
@@ -0,0 +1,29 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// contextTransport binds every outgoing request to ctx, so cancelling it aborts
+// requests that are already in flight.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// bindContext makes the collector stop visiting new URLs once ctx is done.
+// Callbacks aren't copied by Clone, so this has to be called on every clone too.
+func bindContext(ctx context.Context, c *colly.Collector) {
+	c.OnRequest(func(r *colly.Request) {
+		if ctx.Err() != nil {
+			r.Abort()
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrArticleNotFound  = errors.New("the page has no article content")
+	ErrCategoryNotFound = errors.New("the article has no category")
+	ErrInvalidDate      = errors.New("the article date could not be parsed")
+)
+
+// ArticleError is the reason an article of a page couldn't be loaded.
+type ArticleError struct {
+	Link string
+	Err  error
+}
+
+func newArticleError(link string, err error) ArticleError {
+	return ArticleError{Link: link, Err: err}
+}
+
+func (e ArticleError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Link, e.Err)
+}
+
+func (e ArticleError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,75 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+	flightGroup deduplicates concurrent loads of the same key, like singleflight does,
+	but the shared load is cancelled once every caller waiting for it has given up,
+	so a session leaving doesn't abort the load for the others and nobody waiting
+	doesn't leave requests running.
+*/
+type flightGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*flight[T]
+}
+
+type flight[T any] struct {
+	done    chan struct{}
+	val     T
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func (g *flightGroup[T]) Do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flight[T])
+	}
+	f, ok := g.calls[key]
+	if !ok {
+		// The load outlives the caller that started it, it's only cancelled when nobody is waiting
+		fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight[T]{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = f
+
+		go func() {
+			f.val, f.err = fn(fctx)
+			g.forget(key, f)
+			cancel()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		// Under the lock, so no caller joins a flight that's being cancelled
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if g.calls[key] == f {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// forget removes the flight so the next caller starts a new load instead of joining it.
+func (g *flightGroup[T]) forget(key string, f *flight[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.calls[key] == f {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,106 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitWaiters waits until n callers are waiting for the flight of key.
+func waitWaiters[T any](t *testing.T, g *flightGroup[T], key string, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		g.mu.Lock()
+		f, ok := g.calls[key]
+		waiters := 0
+		if ok {
+			waiters = f.waiters
+		}
+		g.mu.Unlock()
+		if waiters == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d callers waiting for %q, want %d", waiters, key, n)
+		}
+	}
+}
+
+func TestFlightGroupDeduplicates(t *testing.T) {
+	var g flightGroup[int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = g.Do(context.Background(), "page", load)
+		}()
+	}
+	waitWaiters(t, &g, "page", len(results))
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("loaded %d times, want once", calls.Load())
+	}
+	for i, got := range results {
+		if got != 42 {
+			t.Errorf("caller %d got %d, want 42", i, got)
+		}
+	}
+}
+
+func TestFlightGroupCancelsAbandonedLoads(t *testing.T) {
+	var g flightGroup[int]
+	cancelled := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return 0, ctx.Err()
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() { _, err := g.Do(ctx1, "page", load); errs <- err }()
+	go func() { _, err := g.Do(ctx2, "page", load); errs <- err }()
+	waitWaiters(t, &g, "page", 2)
+
+	// The load goes on while someone is still waiting for it
+	cancel1()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("Do = %v, want %v", err, context.Canceled)
+	}
+	waitWaiters(t, &g, "page", 1)
+	select {
+	case <-cancelled:
+		t.Fatal("the load was cancelled while a caller was waiting for it")
+	default:
+	}
+
+	// Once the last one gives up it's cancelled, and the next caller starts a new load
+	cancel2()
+	<-errs
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the load wasn't cancelled after every caller gave up")
+	}
+	got, err := g.Do(context.Background(), "page", func(ctx context.Context) (int, error) {
+		return 7, ctx.Err()
+	})
+	if got != 7 || err != nil {
+		t.Errorf("Do after the abandoned load = %d, %v, want 7, nil", got, err)
+	}
+}
@@ -0,0 +1,200 @@
+package scraper
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// healthWindow is how many of the latest matches the hit rate of a selector is measured over
+	healthWindow = 20
+	// healthMinSamples avoids marking the source as degraded because of a single odd page
+	healthMinSamples = 3
+	// degradedHitRate is the hit rate under which a required selector is considered broken
+	degradedHitRate = 0.5
+)
+
+type HealthStatus string
+
+const (
+	HealthOK       HealthStatus = "ok"
+	HealthDegraded HealthStatus = "degraded"
+)
+
+// Health tracks how often each selector matches while scraping. When the site changes
+// its markup the required selectors, the ones of the listing and of the article
+// content, stop matching and the source is degraded until they match again.
+type Health struct {
+	mu        sync.Mutex
+	selectors map[string]*selectorHealth
+	// Names in the order they were first recorded, so reports are stable
+	names    []string
+	status   HealthStatus
+	onChange func(HealthReport)
+}
+
+type selectorHealth struct {
+	required bool
+	// Ring of the latest matches, true for a hit
+	recent  [healthWindow]bool
+	samples int
+	next    int
+
+	hits, misses uint64
+	lastHit      time.Time
+	lastMiss     time.Time
+}
+
+// HealthReport is the state of the selectors at some point.
+type HealthReport struct {
+	Status HealthStatus `json:"status"`
+	// Failing are the required selectors that stopped matching
+	Failing   []string         `json:"failing,omitempty"`
+	Selectors []SelectorHealth `json:"selectors"`
+}
+
+// SelectorHealth is how a single selector is doing.
+type SelectorHealth struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	// HitRate is measured over the latest Samples matches
+	HitRate  float64   `json:"hit_rate"`
+	Samples  int       `json:"samples"`
+	Hits     uint64    `json:"hits"`
+	Misses   uint64    `json:"misses"`
+	LastHit  time.Time `json:"last_hit"`
+	LastMiss time.Time `json:"last_miss"`
+}
+
+// requiredSelectors are the names of the selectors the source can't work without.
+var requiredSelectors = func() map[string]bool {
+	required := map[string]bool{}
+	for _, selector := range append(DefaultSelectors.ListingSelectors(), DefaultSelectors.ArticleSelectors()...) {
+		if !selector.Optional {
+			required[selector.Name] = true
+		}
+	}
+	return required
+}()
+
+func NewHealth() *Health {
+	return &Health{
+		selectors: make(map[string]*selectorHealth),
+		status:    HealthOK,
+	}
+}
+
+// OnChange calls fn every time the status changes, from the goroutine that scraped
+// the page that changed it. fn must not block.
+func (h *Health) OnChange(fn func(HealthReport)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onChange = fn
+}
+
+// Report returns the current state of every selector matched so far.
+func (h *Health) Report() HealthReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.report()
+}
+
+// record adds a match of the selector with the given name, hit is whether it
+// matched something. It's a no-op on a nil Health.
+func (h *Health) record(name string, hit bool) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	s, ok := h.selectors[name]
+	if !ok {
+		s = &selectorHealth{required: requiredSelectors[name]}
+		h.selectors[name] = s
+		h.names = append(h.names, name)
+	}
+	s.recent[s.next] = hit
+	s.next = (s.next + 1) % healthWindow
+	s.samples = min(s.samples+1, healthWindow)
+	if hit {
+		s.hits++
+		s.lastHit = time.Now()
+	} else {
+		s.misses++
+		s.lastMiss = time.Now()
+	}
+	report, changed := h.update()
+	onChange := h.onChange
+	h.mu.Unlock()
+
+	if changed && onChange != nil {
+		onChange(report)
+	}
+}
+
+// reset forgets the latest matches, the selectors were replaced so they say
+// nothing about the new ones. The totals are kept.
+func (h *Health) reset() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	for _, s := range h.selectors {
+		s.recent = [healthWindow]bool{}
+		s.samples = 0
+		s.next = 0
+	}
+	report, changed := h.update()
+	onChange := h.onChange
+	h.mu.Unlock()
+
+	if changed && onChange != nil {
+		onChange(report)
+	}
+}
+
+// update recomputes the status, h.mu must be held.
+func (h *Health) update() (HealthReport, bool) {
+	report := h.report()
+	changed := report.Status != h.status
+	h.status = report.Status
+	return report, changed
+}
+
+// report builds the report from the matches, h.mu must be held.
+func (h *Health) report() HealthReport {
+	report := HealthReport{Status: HealthOK, Selectors: make([]SelectorHealth, 0, len(h.names))}
+	for _, name := range h.names {
+		s := h.selectors[name]
+		rate := s.hitRate()
+		report.Selectors = append(report.Selectors, SelectorHealth{
+			Name:     name,
+			Required: s.required,
+			HitRate:  rate,
+			Samples:  s.samples,
+			Hits:     s.hits,
+			Misses:   s.misses,
+			LastHit:  s.lastHit,
+			LastMiss: s.lastMiss,
+		})
+		if s.required && s.samples >= healthMinSamples && rate < degradedHitRate {
+			report.Status = HealthDegraded
+			report.Failing = append(report.Failing, name)
+		}
+	}
+	return report
+}
+
+func (s *selectorHealth) hitRate() float64 {
+	if s.samples == 0 {
+		return 1
+	}
+	hits := 0
+	for _, hit := range s.recent[:s.samples] {
+		if hit {
+			hits++
+		}
+	}
+	return float64(hits) / float64(s.samples)
+}
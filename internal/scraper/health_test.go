@@ -0,0 +1,80 @@
+package scraper
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	h := NewHealth()
+	var changes []HealthReport
+	h.OnChange(func(report HealthReport) {
+		changes = append(changes, report)
+	})
+
+	// Optional selectors never degrade the source
+	for range healthWindow {
+		h.record("article.lead", false)
+	}
+	// A single miss isn't enough either
+	h.record("listing.article", true)
+	h.record("listing.article", false)
+	if h.Report().Status != HealthOK {
+		t.Fatalf("degraded after a single miss: %+v", h.Report())
+	}
+
+	h.record("listing.article", false)
+	h.record("listing.article", false)
+	report := h.Report()
+	if report.Status != HealthDegraded || strings.Join(report.Failing, ",") != "listing.article" {
+		t.Fatalf("Report = %+v, want listing.article failing", report)
+	}
+
+	// It recovers once the selector matches most of the time again
+	for range healthWindow / 2 {
+		h.record("listing.article", true)
+	}
+	if h.Report().Status != HealthOK {
+		t.Errorf("still degraded after recovering: %+v", h.Report())
+	}
+
+	if len(changes) != 2 || changes[0].Status != HealthDegraded || changes[1].Status != HealthOK {
+		t.Errorf("changes = %+v, want degraded and then ok", changes)
+	}
+
+	for _, selector := range h.Report().Selectors {
+		if selector.Name == "article.lead" && (selector.Required || selector.HitRate != 0 || selector.Misses != healthWindow) {
+			t.Errorf("article.lead = %+v", selector)
+		}
+	}
+}
+
+func TestHealthScraping(t *testing.T) {
+	selectors := *DefaultSelectors
+	selectors.Listing.Article = ".noticia2"
+	q, _ := newTestSource(t, WithSelectors(&selectors))
+
+	for range healthMinSamples {
+		if _, err := q.ListPage(context.Background(), 0); err != nil {
+			t.Fatalf("ListPage: %v", err)
+		}
+	}
+	if report := q.Health().Report(); report.Status != HealthDegraded || strings.Join(report.Failing, ",") != "listing.article" {
+		t.Fatalf("Report = %+v, want listing.article failing", report)
+	}
+
+	// Fixing the selectors starts over
+	q.SetSelectors(DefaultSelectors)
+	if q.Health().Report().Status != HealthOK {
+		t.Error("still degraded after replacing the selectors")
+	}
+	if _, err := q.ListPage(context.Background(), 0); err != nil {
+		t.Fatalf("ListPage: %v", err)
+	}
+	for _, selector := range q.Health().Report().Selectors {
+		if selector.Required && selector.Samples > 0 && selector.Name != "article.container" && selector.HitRate != 1 {
+			t.Errorf("%s has a hit rate of %v with the fixed selectors", selector.Name, selector.HitRate)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Connectivity is whether the site responds, as seen by the last probe.
+type Connectivity string
+
+const (
+	// ConnectivityUnknown is the state before the first probe
+	ConnectivityUnknown  Connectivity = ""
+	ConnectivityOnline   Connectivity = "online"
+	ConnectivityDegraded Connectivity = "degraded"
+	ConnectivityOffline  Connectivity = "offline"
+)
+
+// MonitorPolicy configures a Monitor.
+type MonitorPolicy struct {
+	// Interval is the wait between probes
+	Interval time.Duration
+	// Timeout is how long a probe waits for the site before it's considered offline
+	Timeout time.Duration
+	// SlowLatency is the latency over which the site is considered degraded
+	SlowLatency time.Duration
+}
+
+var DefaultMonitorPolicy = MonitorPolicy{
+	Interval:    30 * time.Second,
+	Timeout:     10 * time.Second,
+	SlowLatency: 3 * time.Second,
+}
+
+// Probe is the result of checking if the site responds.
+type Probe struct {
+	Status  Connectivity
+	Latency time.Duration
+	// StatusCode of the response, 0 when the site couldn't be reached
+	StatusCode int
+	Err        error
+	At         time.Time
+}
+
+// Monitor probes the site periodically, measuring how long it takes to respond. The
+// site is online when it responds in time, degraded when it's slow or responds with
+// a client error, and offline when it can't be reached or responds with a server error.
+type Monitor struct {
+	url    string
+	policy MonitorPolicy
+	client *http.Client
+
+	mu      sync.Mutex
+	last    Probe
+	onProbe func(Probe)
+}
+
+func NewMonitor(url string, policy MonitorPolicy) *Monitor {
+	return &Monitor{
+		url:    url,
+		policy: policy,
+		client: &http.Client{Timeout: policy.Timeout},
+	}
+}
+
+// OnProbe calls fn with the result of every probe, from the goroutine running the
+// monitor. fn must not block.
+func (m *Monitor) OnProbe(fn func(Probe)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onProbe = fn
+}
+
+// Last returns the result of the last probe. A nil Monitor, or one that didn't
+// probe yet, returns a Probe with ConnectivityUnknown.
+func (m *Monitor) Last() Probe {
+	if m == nil {
+		return Probe{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+// Run probes the site every interval until ctx is done.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.policy.Interval)
+	defer ticker.Stop()
+	for {
+		m.Probe(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Probe checks the site once and returns the result, which is also reported to OnProbe.
+func (m *Monitor) Probe(ctx context.Context) Probe {
+	probe := m.probe(ctx)
+	if ctx.Err() != nil {
+		return probe
+	}
+
+	m.mu.Lock()
+	previous := m.last.Status
+	m.last = probe
+	onProbe := m.onProbe
+	m.mu.Unlock()
+
+	if probe.Status != previous {
+		switch probe.Status {
+		case ConnectivityOffline:
+			log.Warn("The site is offline", "status", probe.StatusCode, "error", probe.Err)
+		case ConnectivityDegraded:
+			log.Warn("The site is degraded", "status", probe.StatusCode, "latency", probe.Latency)
+		default:
+			log.Info("The site is online", "latency", probe.Latency)
+		}
+	}
+	if onProbe != nil {
+		onProbe(probe)
+	}
+	return probe
+}
+
+func (m *Monitor) probe(ctx context.Context) Probe {
+	probe := Probe{At: time.Now()}
+	res, err := m.request(ctx, http.MethodHead)
+	// Some servers reject HEAD, the page itself tells if they're up
+	if err == nil && headRejected(res.StatusCode) {
+		res.Body.Close()
+		probe.At = time.Now()
+		res, err = m.request(ctx, http.MethodGet)
+	}
+	probe.Latency = time.Since(probe.At)
+	if err != nil {
+		probe.Status, probe.Err = ConnectivityOffline, err
+		return probe
+	}
+	// Only the headers matter, the body is closed without reading it
+	res.Body.Close()
+
+	probe.StatusCode = res.StatusCode
+	switch {
+	case res.StatusCode >= 500:
+		probe.Status = ConnectivityOffline
+	case res.StatusCode >= 400 || probe.Latency > m.policy.SlowLatency:
+		probe.Status = ConnectivityDegraded
+	default:
+		probe.Status = ConnectivityOnline
+	}
+	return probe
+}
+
+func headRejected(status int) bool {
+	switch status {
+	case http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+func (m *Monitor) request(ctx context.Context, method string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, m.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return m.client.Do(req)
+}
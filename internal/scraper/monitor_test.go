@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMonitor(t *testing.T) {
+	var status atomic.Int32
+	var delay atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Duration(delay.Load()))
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	m := NewMonitor(server.URL, MonitorPolicy{Interval: time.Hour, Timeout: time.Second, SlowLatency: 50 * time.Millisecond})
+	var probes []Probe
+	m.OnProbe(func(probe Probe) {
+		probes = append(probes, probe)
+	})
+	if m.Last().Status != ConnectivityUnknown {
+		t.Errorf("Last before probing = %+v, want unknown", m.Last())
+	}
+
+	tests := []struct {
+		name   string
+		status int
+		delay  time.Duration
+		want   Connectivity
+	}{
+		{"online", http.StatusOK, 0, ConnectivityOnline},
+		{"slow", http.StatusOK, 100 * time.Millisecond, ConnectivityDegraded},
+		{"client error", http.StatusTooManyRequests, 0, ConnectivityDegraded},
+		{"server error", http.StatusBadGateway, 0, ConnectivityOffline},
+	}
+	for _, tt := range tests {
+		status.Store(int32(tt.status))
+		delay.Store(int64(tt.delay))
+		probe := m.Probe(context.Background())
+		if probe.Status != tt.want || probe.StatusCode != tt.status || probe.Latency < tt.delay {
+			t.Errorf("%s: Probe = %+v, want %s", tt.name, probe, tt.want)
+		}
+	}
+
+	server.Close()
+	if probe := m.Probe(context.Background()); probe.Status != ConnectivityOffline || probe.Err == nil {
+		t.Errorf("Probe of a closed server = %+v, want offline with an error", probe)
+	}
+	if len(probes) != len(tests)+1 || m.Last() != probes[len(probes)-1] {
+		t.Errorf("got %d probes and Last = %+v, want every probe reported", len(probes), m.Last())
+	}
+}
+
+func TestMonitorWithoutHead(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusNotImplemented} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(status)
+			}
+		}))
+		m := NewMonitor(server.URL, DefaultMonitorPolicy)
+		if probe := m.Probe(context.Background()); probe.Status != ConnectivityOnline || probe.StatusCode != http.StatusOK {
+			t.Errorf("Probe of a server answering HEAD with %d = %+v, want online", status, probe)
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,96 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+)
+
+// Progress is how the scrape of a listing page is going, sent after the listing is
+// read and after each of its articles is scraped or fails.
+type Progress struct {
+	// Article was just scraped, nil when it failed or only the total is known
+	Article *Article
+	// Done is how many of the Total articles of the page were scraped or failed
+	Done  int
+	Total int
+}
+
+type progressKey struct{}
+
+type progressSink struct {
+	ch   chan<- Progress
+	done <-chan struct{}
+}
+
+// WithProgress returns a context that makes the scrape of a listing page started
+// with it send its progress on ch, so the articles can be shown as they arrive
+// instead of when the slowest one does. Sends wait for ch to be read until ctx
+// is done. Pages that are already cached, or that another session is scraping,
+// arrive all at once without progress.
+func WithProgress(ctx context.Context, ch chan<- Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, progressSink{ch: ch, done: ctx.Done()})
+}
+
+// pageProgress counts the articles of a scrape and sends the progress to the sink
+// of its context. Its methods are no-ops on a nil pageProgress.
+type pageProgress struct {
+	ctx  context.Context
+	sink progressSink
+
+	mu    sync.Mutex
+	done  int
+	total int
+}
+
+// newPageProgress returns the progress of a scrape with ctx, nil when nobody listens to it.
+func newPageProgress(ctx context.Context) *pageProgress {
+	sink, ok := ctx.Value(progressKey{}).(progressSink)
+	if !ok {
+		return nil
+	}
+	return &pageProgress{ctx: ctx, sink: sink}
+}
+
+// listed is called once the links of the listing are known.
+func (p *pageProgress) listed(total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.total = total
+	progress := Progress{Done: p.done, Total: p.total}
+	p.mu.Unlock()
+	p.send(progress)
+}
+
+// scraped is called with each article of the listing that was scraped.
+func (p *pageProgress) scraped(article Article) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.done++
+	progress := Progress{Article: &article, Done: p.done, Total: p.total}
+	p.mu.Unlock()
+	p.send(progress)
+}
+
+// failed is called with each article of the listing that couldn't be scraped.
+func (p *pageProgress) failed() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.done++
+	progress := Progress{Done: p.done, Total: p.total}
+	p.mu.Unlock()
+	p.send(progress)
+}
+
+func (p *pageProgress) send(progress Progress) {
+	select {
+	case p.sink.ch <- progress:
+	case <-p.sink.done:
+	case <-p.ctx.Done():
+	}
+}
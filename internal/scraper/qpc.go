@@ -0,0 +1,602 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+	"github.com/charmbracelet/log"
+	"golang.org/x/net/html"
+
+	"qpc-tui/internal/body"
+	"qpc-tui/internal/spanishdate"
+)
+
+const qpcBaseURL = "https://www.quepensaschacabuco.com"
+
+// qpcFallbackCategories are used when the categories can't be discovered from the site's menu
+var qpcFallbackCategories = []Category{
+	{Id: 8, Name: "Policiales"},
+	{Id: 48, Name: "Sociedad"},
+	{Id: 75, Name: "Automotores"},
+}
+
+var (
+	categoryClassRegexp = regexp.MustCompile(`categoria_(\d+)`)
+	categoryLinkRegexp  = regexp.MustCompile(`/categoria/(\d+)`)
+)
+
+// Limits keep the scraper polite toward the site.
+type Limits struct {
+	// Parallelism is the maximum of concurrent requests of a single scrape
+	Parallelism int
+	// RandomDelay is the maximum random delay added after each request of a scrape
+	RandomDelay time.Duration
+	// Budget is shared by every scrape, so it bounds the requests of all the sessions together
+	Budget *Budget
+}
+
+var DefaultLimits = Limits{
+	Parallelism: 2,
+	RandomDelay: 500 * time.Millisecond,
+	Budget:      NewBudget(4, 200*time.Millisecond),
+}
+
+// QPC is the Source for www.quepensaschacabuco.com.
+type QPC struct {
+	baseURL   string
+	domain    string
+	limits    Limits
+	retry     RetryPolicy
+	breaker   *Breaker
+	transport http.RoundTripper
+	// selectors can be replaced while scraping, each scrape uses the ones it started with
+	selectors atomic.Pointer[Selectors]
+	health    *Health
+}
+
+type QPCOption func(*QPC)
+
+// WithLimits replaces DefaultLimits.
+func WithLimits(limits Limits) QPCOption {
+	return func(q *QPC) {
+		q.limits = limits
+	}
+}
+
+// WithBaseURL scrapes a copy of the site served somewhere else, like a test server.
+func WithBaseURL(baseURL string) QPCOption {
+	return func(q *QPC) {
+		q.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithTransport makes the requests through the given transport instead of http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) QPCOption {
+	return func(q *QPC) {
+		q.transport = transport
+	}
+}
+
+// WithSelectors replaces DefaultSelectors.
+func WithSelectors(selectors *Selectors) QPCOption {
+	return func(q *QPC) {
+		q.selectors.Store(selectors)
+	}
+}
+
+// WithBreaker replaces DefaultBreakerPolicy.
+func WithBreaker(policy BreakerPolicy) QPCOption {
+	return func(q *QPC) {
+		q.breaker = NewBreaker(policy)
+	}
+}
+
+// WithRetry replaces DefaultRetryPolicy.
+func WithRetry(policy RetryPolicy) QPCOption {
+	return func(q *QPC) {
+		q.retry = policy
+	}
+}
+
+func NewQPC(opts ...QPCOption) *QPC {
+	q := &QPC{
+		baseURL:   qpcBaseURL,
+		limits:    DefaultLimits,
+		retry:     DefaultRetryPolicy,
+		breaker:   NewBreaker(DefaultBreakerPolicy),
+		transport: http.DefaultTransport,
+		health:    NewHealth(),
+	}
+	q.selectors.Store(DefaultSelectors)
+	for _, opt := range opts {
+		opt(q)
+	}
+	// Only the site's own domain is scraped, links to other sites are ignored
+	if u, err := url.Parse(q.baseURL); err == nil {
+		q.domain = u.Hostname()
+	}
+	// Every attempt waits for its turn in the budget before its timeout starts
+	q.transport = retryTransport{policy: q.retry, budget: q.limits.Budget, base: q.transport}
+	// The breaker sees the outcome after the retries, a request that recovered isn't a failure
+	if q.breaker.threshold > 0 {
+		q.transport = breakerTransport{breaker: q.breaker, base: q.transport}
+	} else {
+		q.breaker = nil
+	}
+	return q
+}
+
+func (q *QPC) Name() string {
+	return "Qué Pensás Chacabuco"
+}
+
+// BaseURL returns the URL of the site's home page.
+func (q *QPC) BaseURL() string {
+	return q.baseURL + "/"
+}
+
+// Selectors returns the selectors the scrapes that start now use.
+func (q *QPC) Selectors() *Selectors {
+	return q.selectors.Load()
+}
+
+// SetSelectors replaces the selectors, the scrapes in progress keep the old ones.
+// The health starts over, the old hit rates say nothing about the new selectors.
+func (q *QPC) SetSelectors(selectors *Selectors) {
+	q.selectors.Store(selectors)
+	q.health.reset()
+}
+
+// Breaker returns the breaker that pauses the requests while the site is down, nil if it's disabled.
+func (q *QPC) Breaker() *Breaker {
+	return q.breaker
+}
+
+// Health returns how well the selectors are matching the site.
+func (q *QPC) Health() *Health {
+	return q.health
+}
+
+// Categories discovers the sections from the links of the site's menu. If it can't,
+// it falls back to the sections known when this was written.
+func (q *QPC) Categories(ctx context.Context) ([]Category, error) {
+	categories, err := q.discoverCategories(ctx)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		log.Warn("Could not discover the categories, using the known ones", "error", err)
+		return qpcFallbackCategories, nil
+	}
+	return categories, nil
+}
+
+func (q *QPC) discoverCategories(ctx context.Context) ([]Category, error) {
+	c := q.newCollector(ctx)
+
+	var categories []Category
+	found := map[int]bool{}
+	c.OnHTML(q.Selectors().Menu.Category, func(e *colly.HTMLElement) {
+		id, ok := categoryId(e)
+		name := strings.TrimSpace(e.Text)
+		if !ok || found[id] || name == "" {
+			return
+		}
+		found[id] = true
+		categories = append(categories, Category{
+			Id:   id,
+			Name: name,
+			URL:  e.Request.AbsoluteURL(e.Attr("href")),
+		})
+	})
+
+	if err := c.Visit(q.baseURL + "/"); err != nil {
+		return nil, err
+	}
+	c.Wait()
+
+	if len(categories) == 0 {
+		return nil, errors.New("no categories found in the menu")
+	}
+	return categories, nil
+}
+
+// categoryId finds the id of the category a menu link points to, either from the
+// categoria_N class of the link or its item, or from the link itself.
+func categoryId(e *colly.HTMLElement) (int, bool) {
+	classes := e.Attr("class") + " " + e.DOM.Parent().AttrOr("class", "")
+	matches := categoryClassRegexp.FindStringSubmatch(classes)
+	if matches == nil {
+		matches = categoryLinkRegexp.FindStringSubmatch(e.Attr("href"))
+	}
+	if matches == nil {
+		return 0, false
+	}
+	id, err := strconv.Atoi(matches[1])
+	return id, err == nil
+}
+
+func setupCollectors(ctx context.Context, c *colly.Collector, sel *Selectors, health *Health, progress *pageProgress, links *[]string, articles *[]Article, failures *[]ArticleError, mu *sync.Mutex, wg *sync.WaitGroup, canContinue *bool, canGoBack *bool) {
+	setupMainCollector(c, sel, health, links, "", canContinue, canGoBack)
+	contentCollector := setupContentCollector(ctx, c, sel, health, progress, articles, failures, mu)
+	setupOnScrapedCallback(ctx, c, contentCollector, progress, links, failures, mu, wg)
+}
+
+func setupMainCollector(c *colly.Collector, sel *Selectors, health *Health, links *[]string, additionalClass string, canContinue *bool, canGoBack *bool) {
+	class := sel.Listing.Article + additionalClass
+
+	c.OnHTML(class, func(e *colly.HTMLElement) {
+		parent := e.DOM.Closest("[" + sel.Listing.LinkAttribute + "]")
+
+		if link := parent.AttrOr(sel.Listing.LinkAttribute, ""); link != "" {
+			*links = append(*links, e.Request.AbsoluteURL(link))
+		}
+	})
+
+	c.OnScraped(func(r *colly.Response) {
+		health.record("listing.article", len(*links) > 0)
+		if len(*links) == 0 {
+			log.Error("No articles found in the listing", "selector", "listing.article", "url", r.Request.URL)
+		}
+	})
+
+	if sel.Listing.Pagination == "" {
+		return
+	}
+	paginated := false
+	c.OnHTML(sel.Listing.Pagination, func(e *colly.HTMLElement) {
+		paginated = true
+		if e.Text == sel.Listing.NextText {
+			*canContinue = true
+		}
+		if e.Text == sel.Listing.PreviousText {
+			*canGoBack = true
+		}
+	})
+	c.OnScraped(func(r *colly.Response) {
+		health.record("listing.pagination", paginated)
+	})
+}
+
+func setupContentCollector(ctx context.Context, c *colly.Collector, sel *Selectors, health *Health, progress *pageProgress, articles *[]Article, failures *[]ArticleError, mu *sync.Mutex) *colly.Collector {
+	contentCollector := c.Clone()
+	bindContext(ctx, contentCollector)
+
+	contentCollector.OnHTML(sel.Article.Container, func(e *colly.HTMLElement) {
+		e.Request.Ctx.Put("found", "true")
+		article, err := parseArticle(e, sel, health)
+
+		mu.Lock()
+		if err != nil {
+			*failures = append(*failures, newArticleError(e.Request.URL.String(), err))
+			mu.Unlock()
+			progress.failed()
+			return
+		}
+		*articles = append(*articles, *article)
+		mu.Unlock()
+		progress.scraped(*article)
+	})
+
+	// A page without the article content, most likely the link points somewhere else
+	contentCollector.OnScraped(func(r *colly.Response) {
+		health.record("article.container", r.Ctx.Get("found") != "")
+		if r.Ctx.Get("found") == "" {
+			mu.Lock()
+			*failures = append(*failures, newArticleError(r.Request.URL.String(), ErrArticleNotFound))
+			mu.Unlock()
+			progress.failed()
+		}
+	})
+	return contentCollector
+}
+
+func parseArticle(e *colly.HTMLElement, sel *Selectors, health *Health) (*Article, error) {
+	info := e.ChildText(sel.Article.Date)
+	title := e.ChildText(sel.Article.Title)
+	category := e.ChildText(sel.Article.Category)
+	health.record("article.date", info != "")
+	health.record("article.title", title != "")
+	health.record("article.category", category != "")
+	classes := e.DOM.AttrOr("class", "")
+
+	matches := categoryClassRegexp.FindStringSubmatch(classes)
+	if len(matches) < 2 {
+		return nil, ErrCategoryNotFound
+	}
+
+	categoryId, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return nil, fmt.Errorf("parsing the category id: %w", err)
+	}
+
+	// The lead and the main image are outside of the body, the author line starts with "Por"
+	var lead, author, imageURL, imageCaption string
+	var tags []string
+	if sel.Article.Lead != "" {
+		lead = strings.Join(strings.Fields(e.ChildText(sel.Article.Lead)), " ")
+		health.record("article.lead", lead != "")
+	}
+	if sel.Article.Author != "" {
+		author = strings.TrimSpace(strings.TrimPrefix(e.ChildText(sel.Article.Author), "Por "))
+		health.record("article.author", author != "")
+	}
+	if sel.Article.Tags != "" {
+		for _, tag := range e.ChildTexts(sel.Article.Tags) {
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		health.record("article.tags", len(tags) > 0)
+	}
+	if sel.Article.Image != "" {
+		e.ForEachWithBreak(sel.Article.Image, func(_ int, img *colly.HTMLElement) bool {
+			imageURL = imageSource(img)
+			return imageURL == ""
+		})
+		health.record("article.image", imageURL != "")
+	}
+	if sel.Article.ImageCaption != "" {
+		imageCaption = e.ChildText(sel.Article.ImageCaption)
+		health.record("article.image_caption", imageCaption != "")
+	}
+
+	for i, element := range sel.Article.Remove {
+		removed := e.DOM.Find(element).Remove()
+		health.record(fmt.Sprintf("article.remove[%d]", i), removed.Length() > 0)
+	}
+
+	articleBody := e.DOM.Find(sel.Article.Body)
+	health.record("article.body", articleBody.Length() > 0)
+	opts := body.Options{Resolve: e.Request.AbsoluteURL}
+	if sel.Article.Gallery != "" {
+		galleries := articleBody.Find(sel.Article.Gallery)
+		health.record("article.gallery", galleries.Length() > 0)
+		opts.Gallery = func(n *html.Node) bool { return galleries.IsNodes(n) }
+	}
+	var blocks body.Blocks
+	if articleBody.Length() > 0 {
+		blocks = body.Parse(articleBody.Nodes[0], opts)
+	}
+
+	// What's left of the body are the article's own images and videos
+	var images, videos []string
+	for _, block := range blocks {
+		switch {
+		case block.Kind == body.Image:
+			images = appendUnique(images, block.URL)
+		case block.Kind == body.Gallery:
+			for _, image := range block.Images {
+				images = appendUnique(images, image.URL)
+			}
+		case block.Video():
+			videos = appendUnique(videos, block.URL)
+		}
+	}
+	articleBody.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		if link := a.AttrOr("href", ""); body.IsVideo(link) {
+			videos = appendUnique(videos, e.Request.AbsoluteURL(link))
+		}
+	})
+
+	t, err := spanishdate.Parse(info)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDate, err)
+	}
+
+	link := e.Request.URL.String()
+	return &Article{
+		ID:         ArticleID(link),
+		Title:      title,
+		Date:       t,
+		Category:   category,
+		CategoryId: categoryId,
+		Body:       blocks,
+		Link:       link,
+
+		Lead:         lead,
+		Author:       author,
+		Tags:         tags,
+		ImageURL:     imageURL,
+		ImageCaption: imageCaption,
+		Images:       images,
+		Videos:       videos,
+	}, nil
+}
+
+// imageSource returns the absolute URL of the image, the ones that are loaded lazily
+// have a placeholder as src and the real one in data-src.
+func imageSource(img *colly.HTMLElement) string {
+	for _, attr := range []string{"data-src", "src"} {
+		src := strings.TrimSpace(img.Attr(attr))
+		if src != "" && !strings.HasPrefix(src, "data:") {
+			return img.Request.AbsoluteURL(src)
+		}
+	}
+	return ""
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func setupOnScrapedCallback(ctx context.Context, c *colly.Collector, contentCollector *colly.Collector, progress *pageProgress, links *[]string, failures *[]ArticleError, mu *sync.Mutex, wg *sync.WaitGroup) {
+	c.OnScraped(func(r *colly.Response) {
+		progress.listed(len(*links))
+
+		for _, link := range *links {
+			// Don't start new visits once the caller is no longer waiting for them
+			if ctx.Err() != nil {
+				break
+			}
+
+			wg.Add(1)
+
+			go func(url string) {
+
+				defer wg.Done()
+				if err := contentCollector.Visit(url); err != nil && ctx.Err() == nil {
+					mu.Lock()
+					*failures = append(*failures, newArticleError(url, err))
+					mu.Unlock()
+					progress.failed()
+				}
+			}(link)
+		}
+		*links = []string{}
+	})
+}
+
+func (q *QPC) newCollector(ctx context.Context) *colly.Collector {
+	c := colly.NewCollector(
+		colly.AllowedDomains(q.domain),
+	)
+	c.WithTransport(contextTransport{ctx: ctx, base: q.transport})
+	// Every attempt has its own timeout, one for the whole request would also count the retries
+	c.SetRequestTimeout(0)
+	// The rule is shared with the clones of the collector, so it bounds the whole scrape
+	c.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Parallelism: q.limits.Parallelism,
+		RandomDelay: q.limits.RandomDelay,
+	})
+	bindContext(ctx, c)
+	return c
+}
+
+func (q *QPC) ListPage(ctx context.Context, page int) (*Page, error) {
+	return q.listPage(ctx, q.pageURL(page), page)
+}
+
+// ListCategoryPage scrapes the section's own listing, it has the same markup as the main one.
+func (q *QPC) ListCategoryPage(ctx context.Context, category Category, page int) (*Page, error) {
+	return q.listPage(ctx, q.categoryPageURL(category, page), page)
+}
+
+func (q *QPC) listPage(ctx context.Context, pageURL string, page int) (*Page, error) {
+	c := q.newCollector(ctx)
+
+	var (
+		links       []string
+		articles    []Article
+		failures    []ArticleError
+		canContinue bool
+		canGoBack   bool
+
+		mu sync.Mutex
+
+		wg sync.WaitGroup
+	)
+
+	setupCollectors(ctx, c, q.Selectors(), q.health, newPageProgress(ctx), &links, &articles, &failures, &mu, &wg, &canContinue, &canGoBack)
+
+	err := c.Visit(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Wait()
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, failure := range failures {
+		log.Warn("Could not load article", "link", failure.Link, "error", failure.Err)
+	}
+
+	return &Page{
+		Number:      page,
+		Articles:    articles,
+		Failures:    failures,
+		CanContinue: canContinue,
+		CanGoBack:   canGoBack,
+		FetchedAt:   time.Now(),
+	}, nil
+}
+
+func (q *QPC) ListLinks(ctx context.Context, page int) (*Page, error) {
+	c := q.newCollector(ctx)
+
+	var (
+		links       []string
+		canContinue bool
+		canGoBack   bool
+	)
+
+	setupMainCollector(c, q.Selectors(), q.health, &links, "", &canContinue, &canGoBack)
+
+	if err := c.Visit(q.pageURL(page)); err != nil {
+		return nil, err
+	}
+	c.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Page{
+		Number:      page,
+		Links:       links,
+		CanContinue: canContinue,
+		CanGoBack:   canGoBack,
+	}, nil
+}
+
+func (q *QPC) pageURL(page int) string {
+	return fmt.Sprintf("%s/entradas/%d/", q.baseURL, page)
+}
+
+// categoryPageURL returns the given page of the section's listing. The fallback
+// categories have no URL, so it's built from the id.
+func (q *QPC) categoryPageURL(category Category, page int) string {
+	categoryURL := category.URL
+	if categoryURL == "" {
+		categoryURL = fmt.Sprintf("%s/categoria/%d/", q.baseURL, category.Id)
+	}
+	return fmt.Sprintf("%s/%d/", strings.TrimSuffix(categoryURL, "/"), page)
+}
+
+func (q *QPC) FetchArticle(ctx context.Context, link string) (*Article, error) {
+	var (
+		articles []Article
+		failures []ArticleError
+		mu       sync.Mutex
+	)
+
+	contentCollector := setupContentCollector(ctx, q.newCollector(ctx), q.Selectors(), q.health, nil, &articles, &failures, &mu)
+	if err := contentCollector.Visit(link); err != nil {
+		return nil, err
+	}
+	contentCollector.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(failures) > 0 {
+		return nil, failures[0]
+	}
+	if len(articles) == 0 {
+		return nil, newArticleError(link, ErrArticleNotFound)
+	}
+	return &articles[0], nil
+}
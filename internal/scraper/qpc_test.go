@@ -0,0 +1,574 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"qpc-tui/internal/body"
+	"qpc-tui/internal/spanishdate"
+)
+
+// newTestSource returns a QPC source that scrapes the recorded pages in testdata/site.
+func newTestSource(t *testing.T, opts ...QPCOption) (*QPC, *httptest.Server) {
+	t.Helper()
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata/site")))
+	t.Cleanup(server.Close)
+	return newTestSourceFor(server, opts...), server
+}
+
+func newTestSourceFor(server *httptest.Server, opts ...QPCOption) *QPC {
+	opts = append([]QPCOption{
+		WithBaseURL(server.URL),
+		WithTransport(server.Client().Transport),
+		WithLimits(Limits{Parallelism: 4}),
+		WithRetry(RetryPolicy{Attempts: 1}),
+	}, opts...)
+	return NewQPC(opts...)
+}
+
+func TestListPage(t *testing.T) {
+	q, server := newTestSource(t)
+
+	p, err := q.ListPage(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("ListPage: %v", err)
+	}
+
+	if p.Number != 0 {
+		t.Errorf("Number = %d, want 0", p.Number)
+	}
+	if !p.CanContinue {
+		t.Error("CanContinue = false, want true")
+	}
+	if p.CanGoBack {
+		t.Error("CanGoBack = true, want false")
+	}
+
+	titles := make([]string, len(p.Articles))
+	for i, article := range p.Articles {
+		titles[i] = article.Title
+	}
+	sort.Strings(titles)
+	if want := []string{"Festival de la primavera", "Robo en la plaza"}; strings.Join(titles, "|") != strings.Join(want, "|") {
+		t.Errorf("titles = %q, want %q", titles, want)
+	}
+
+	failures := map[string]error{}
+	for _, failure := range p.Failures {
+		failures[failure.Link] = failure.Err
+	}
+	if len(failures) != 2 {
+		t.Fatalf("got %d failures, want 2: %v", len(failures), p.Failures)
+	}
+	if err := failures[server.URL+"/nota/1004/sin-categoria/"]; !errors.Is(err, ErrCategoryNotFound) {
+		t.Errorf("failure of the article without category = %v, want %v", err, ErrCategoryNotFound)
+	}
+	if err := failures[server.URL+"/nota/1005/pagina-sin-nota/"]; !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("failure of the page without article = %v, want %v", err, ErrArticleNotFound)
+	}
+}
+
+func TestListPageLastPage(t *testing.T) {
+	q, _ := newTestSource(t)
+
+	p, err := q.ListPage(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("ListPage: %v", err)
+	}
+	if p.CanContinue {
+		t.Error("CanContinue = true, want false")
+	}
+	if !p.CanGoBack {
+		t.Error("CanGoBack = false, want true")
+	}
+	if len(p.Articles) != 1 || p.Articles[0].CategoryId != 75 {
+		t.Errorf("articles = %+v, want the Automotores article", p.Articles)
+	}
+}
+
+func TestListPageMissing(t *testing.T) {
+	q, _ := newTestSource(t)
+
+	if _, err := q.ListPage(context.Background(), 99); err == nil {
+		t.Error("ListPage of a page that doesn't exist succeeded")
+	}
+}
+
+func TestListPageProgress(t *testing.T) {
+	q, _ := newTestSource(t)
+
+	ch := make(chan Progress)
+	received := make(chan []Progress)
+	go func() {
+		var progress []Progress
+		for p := range ch {
+			progress = append(progress, p)
+		}
+		received <- progress
+	}()
+
+	p, err := q.ListPage(WithProgress(context.Background(), ch), 0)
+	close(ch)
+	if err != nil {
+		t.Fatalf("ListPage: %v", err)
+	}
+	progress := <-received
+
+	// The total first, then one progress for each article scraped or failed
+	if len(progress) != 5 || progress[0].Total != 4 || progress[0].Done != 0 || progress[0].Article != nil {
+		t.Fatalf("progress = %+v, want the total and then 4 articles", progress)
+	}
+	var titles []string
+	done := map[int]bool{}
+	for _, p := range progress[1:] {
+		done[p.Done] = true
+		if p.Total != 4 {
+			t.Errorf("Total = %d, want 4", p.Total)
+		}
+		if p.Article != nil {
+			titles = append(titles, p.Article.Title)
+		}
+	}
+	sort.Strings(titles)
+	if want := []string{"Festival de la primavera", "Robo en la plaza"}; strings.Join(titles, "|") != strings.Join(want, "|") {
+		t.Errorf("streamed titles = %q, want %q", titles, want)
+	}
+	if len(done) != 4 || !done[4] {
+		t.Errorf("Done values = %v, want 1 to 4", done)
+	}
+	if len(p.Articles) != 2 || len(p.Failures) != 2 {
+		t.Errorf("page has %d articles and %d failures, want 2 and 2", len(p.Articles), len(p.Failures))
+	}
+}
+
+func TestListPageProgressNotRead(t *testing.T) {
+	q, _ := newTestSource(t)
+
+	// Nobody reads the progress, the scrape must still end once ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := q.ListPage(WithProgress(ctx, make(chan Progress)), 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ListPage = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestListLinks(t *testing.T) {
+	q, server := newTestSource(t)
+
+	p, err := q.ListLinks(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("ListLinks: %v", err)
+	}
+
+	want := []string{
+		server.URL + "/nota/1001/robo-en-la-plaza/",
+		server.URL + "/nota/1002/festival-de-la-primavera/",
+		server.URL + "/nota/1004/sin-categoria/",
+		server.URL + "/nota/1005/pagina-sin-nota/",
+	}
+	if strings.Join(p.Links, "|") != strings.Join(want, "|") {
+		t.Errorf("Links = %q, want %q", p.Links, want)
+	}
+	if len(p.Articles) != 0 {
+		t.Errorf("ListLinks scraped %d articles, want none", len(p.Articles))
+	}
+	if !p.CanContinue || p.CanGoBack {
+		t.Errorf("CanContinue, CanGoBack = %v, %v, want true, false", p.CanContinue, p.CanGoBack)
+	}
+}
+
+func TestListCategoryPage(t *testing.T) {
+	q, server := newTestSource(t)
+	policiales := Category{Id: 8, Name: "Policiales", URL: server.URL + "/categoria/8/policiales/"}
+
+	p, err := q.ListCategoryPage(context.Background(), policiales, 0)
+	if err != nil {
+		t.Fatalf("ListCategoryPage: %v", err)
+	}
+	if len(p.Articles) != 1 || p.Articles[0].Title != "Robo en la plaza" {
+		t.Errorf("articles = %+v, want the Policiales article", p.Articles)
+	}
+	if !p.CanContinue || p.CanGoBack {
+		t.Errorf("CanContinue, CanGoBack = %v, %v, want true, false", p.CanContinue, p.CanGoBack)
+	}
+
+	p, err = q.ListCategoryPage(context.Background(), policiales, 1)
+	if err != nil {
+		t.Fatalf("ListCategoryPage: %v", err)
+	}
+	if p.Number != 1 || p.CanContinue || !p.CanGoBack {
+		t.Errorf("Number, CanContinue, CanGoBack = %d, %v, %v, want 1, false, true", p.Number, p.CanContinue, p.CanGoBack)
+	}
+	if len(p.Failures) != 1 || !errors.Is(p.Failures[0], ErrArticleNotFound) {
+		t.Errorf("Failures = %v, want the page without article", p.Failures)
+	}
+}
+
+func TestCategoryPageURL(t *testing.T) {
+	q := NewQPC()
+
+	tests := []struct {
+		category Category
+		page     int
+		want     string
+	}{
+		{Category{Id: 8, URL: qpcBaseURL + "/categoria/8/policiales/"}, 2, qpcBaseURL + "/categoria/8/policiales/2/"},
+		{Category{Id: 8, URL: qpcBaseURL + "/categoria/8/policiales"}, 0, qpcBaseURL + "/categoria/8/policiales/0/"},
+		{Category{Id: 48}, 1, qpcBaseURL + "/categoria/48/1/"},
+	}
+	for _, tt := range tests {
+		if got := q.categoryPageURL(tt.category, tt.page); got != tt.want {
+			t.Errorf("categoryPageURL(%+v, %d) = %q, want %q", tt.category, tt.page, got, tt.want)
+		}
+	}
+}
+
+func TestFetchArticle(t *testing.T) {
+	q, server := newTestSource(t)
+	link := server.URL + "/nota/1001/robo-en-la-plaza/"
+
+	article, err := q.FetchArticle(context.Background(), link)
+	if err != nil {
+		t.Fatalf("FetchArticle: %v", err)
+	}
+
+	if article.Title != "Robo en la plaza" {
+		t.Errorf("Title = %q", article.Title)
+	}
+	if article.Category != "Policiales" || article.CategoryId != 8 {
+		t.Errorf("Category, CategoryId = %q, %d, want Policiales, 8", article.Category, article.CategoryId)
+	}
+	if want := time.Date(2024, time.January, 15, 10, 30, 0, 0, spanishdate.Location); !article.Date.Equal(want) {
+		t.Errorf("Date = %v, want %v", article.Date, want)
+	}
+	if article.ID != "nota/1001/robo-en-la-plaza" {
+		t.Errorf("ID = %q", article.ID)
+	}
+	if article.Link != link {
+		t.Errorf("Link = %q, want %q", article.Link, link)
+	}
+
+	// The ad and the share buttons were removed
+	want := body.Blocks{
+		{Kind: body.Paragraph, Text: "Un hombre fue detenido tras robar una bicicleta en la plaza principal."},
+		{Kind: body.Image, URL: server.URL + "/img/1001-bicicleta.jpg", Caption: "La bicicleta recuperada"},
+		{Kind: body.Paragraph, Text: "La policía recuperó el rodado minutos después."},
+		{Kind: body.Embed, URL: "https://www.youtube.com/embed/abc123"},
+		{Kind: body.Paragraph, Text: "Mirá el video en YouTube.", Links: []body.Link{{Start: 18, End: 25, URL: "https://www.youtube.com/embed/abc123"}}},
+	}
+	if !reflect.DeepEqual(article.Body, want) {
+		t.Errorf("Body = %+v, want %+v", article.Body, want)
+	}
+}
+
+func TestFetchArticleMetadata(t *testing.T) {
+	q, server := newTestSource(t)
+
+	article, err := q.FetchArticle(context.Background(), server.URL+"/nota/1001/robo-en-la-plaza/")
+	if err != nil {
+		t.Fatalf("FetchArticle: %v", err)
+	}
+
+	if want := "Un vecino avisó a la policía y el ladrón fue detenido a pocas cuadras."; article.Lead != want {
+		t.Errorf("Lead = %q, want %q", article.Lead, want)
+	}
+	if article.Author != "María Gómez" {
+		t.Errorf("Author = %q, want %q", article.Author, "María Gómez")
+	}
+	if want := []string{"Robo", "Plaza"}; strings.Join(article.Tags, "|") != strings.Join(want, "|") {
+		t.Errorf("Tags = %q, want %q", article.Tags, want)
+	}
+	if want := server.URL + "/img/1001.jpg"; article.ImageURL != want {
+		t.Errorf("ImageURL = %q, want %q", article.ImageURL, want)
+	}
+	if want := "La plaza principal de Chacabuco."; article.ImageCaption != want {
+		t.Errorf("ImageCaption = %q, want %q", article.ImageCaption, want)
+	}
+	// The image of the ad was removed with it
+	if want := []string{server.URL + "/img/1001-bicicleta.jpg"}; strings.Join(article.Images, "|") != strings.Join(want, "|") {
+		t.Errorf("Images = %q, want %q", article.Images, want)
+	}
+	if want := []string{"https://www.youtube.com/embed/abc123"}; strings.Join(article.Videos, "|") != strings.Join(want, "|") {
+		t.Errorf("Videos = %q, want %q", article.Videos, want)
+	}
+}
+
+func TestFetchArticleGalleriesAndEmbeds(t *testing.T) {
+	q, server := newTestSource(t)
+
+	article, err := q.FetchArticle(context.Background(), server.URL+"/nota/1002/festival-de-la-primavera/")
+	if err != nil {
+		t.Fatalf("FetchArticle: %v", err)
+	}
+	if want := time.Date(2024, time.September, 21, 18, 0, 0, 0, spanishdate.Location); article.CategoryId != 48 || !article.Date.Equal(want) {
+		t.Errorf("CategoryId, Date = %d, %v", article.CategoryId, article.Date)
+	}
+
+	// The gallery is a list of its images, without the clones of the carousel, and the
+	// tweet a post. The related articles were removed.
+	want := body.Blocks{
+		{Kind: body.Paragraph, Text: "El festival reunió a cientos de vecinos en el parque."},
+		{Kind: body.Gallery, Images: []body.Block{
+			{Kind: body.Image, URL: server.URL + "/img/festival-1.jpg", Caption: "El escenario principal"},
+			{Kind: body.Image, URL: server.URL + "/img/festival-2.jpg", Caption: "Los puestos de comida"},
+		}},
+		{Kind: body.Social, Caption: "Twitter", Text: "¡Gracias a todos los que vinieron al festival!", URL: "https://twitter.com/munichacabuco/status/1837"},
+		{Kind: body.Paragraph, Text: "Habrá una nueva edición el año próximo."},
+	}
+	if !reflect.DeepEqual(article.Body, want) {
+		t.Errorf("Body = %+v, want %+v", article.Body, want)
+	}
+	if len(article.Images) != 2 {
+		t.Errorf("Images = %q, want the images of the gallery", article.Images)
+	}
+	if markdown := article.Body.Markdown(); strings.Contains(markdown, "Notas relacionadas") {
+		t.Errorf("Body has the related articles, they should have been removed:\n%s", markdown)
+	}
+}
+
+func TestFetchArticleErrors(t *testing.T) {
+	q, server := newTestSource(t)
+
+	tests := []struct {
+		path string
+		want error
+	}{
+		{"/nota/1004/sin-categoria/", ErrCategoryNotFound},
+		{"/nota/1005/pagina-sin-nota/", ErrArticleNotFound},
+	}
+	for _, tt := range tests {
+		_, err := q.FetchArticle(context.Background(), server.URL+tt.path)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("FetchArticle(%s) = %v, want %v", tt.path, err, tt.want)
+		}
+	}
+}
+
+func TestFetchArticleCancelled(t *testing.T) {
+	q, server := newTestSource(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.FetchArticle(ctx, server.URL+"/nota/1001/robo-en-la-plaza/"); !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchArticle with a cancelled context = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestCategories(t *testing.T) {
+	q, server := newTestSource(t)
+
+	categories, err := q.Categories(context.Background())
+	if err != nil {
+		t.Fatalf("Categories: %v", err)
+	}
+
+	want := []Category{
+		{Id: 8, Name: "Policiales", URL: server.URL + "/categoria/8/policiales/"},
+		{Id: 48, Name: "Sociedad", URL: server.URL + "/categoria/48/sociedad/"},
+		{Id: 75, Name: "Automotores", URL: server.URL + "/categoria/75/automotores/"},
+		{Id: 12, Name: "Deportes", URL: server.URL + "/categoria/12/deportes/"},
+	}
+	if len(categories) != len(want) {
+		t.Fatalf("Categories = %+v, want %+v", categories, want)
+	}
+	for i := range want {
+		if categories[i] != want[i] {
+			t.Errorf("category %d = %+v, want %+v", i, categories[i], want[i])
+		}
+	}
+}
+
+func TestCategoriesFallback(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	categories, err := newTestSourceFor(server).Categories(context.Background())
+	if err != nil {
+		t.Fatalf("Categories: %v", err)
+	}
+	if len(categories) != len(qpcFallbackCategories) {
+		t.Errorf("Categories = %+v, want the fallback ones", categories)
+	}
+}
+
+func TestArticleID(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"https://www.quepensaschacabuco.com/nota/1001/robo-en-la-plaza/", "nota/1001/robo-en-la-plaza"},
+		{"http://127.0.0.1:8080/nota/1001/robo-en-la-plaza", "nota/1001/robo-en-la-plaza"},
+		{"https://www.quepensaschacabuco.com/nota/1001/robo-en-la-plaza/?utm_source=x", "nota/1001/robo-en-la-plaza"},
+		{"https://www.quepensaschacabuco.com/", "https://www.quepensaschacabuco.com/"},
+	}
+	for _, tt := range tests {
+		if got := ArticleID(tt.link); got != tt.want {
+			t.Errorf("ArticleID(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var requests atomic.Int32
+	files := http.FileServer(http.Dir("testdata/site"))
+	// The first request of every page fails
+	var mu sync.Mutex
+	failed := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		mu.Lock()
+		first := !failed[r.URL.Path]
+		failed[r.URL.Path] = true
+		mu.Unlock()
+		if first {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		files.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	q := newTestSourceFor(server, WithLimits(Limits{Parallelism: 1}), WithRetry(RetryPolicy{
+		Attempts:  2,
+		BaseDelay: time.Millisecond,
+		MaxDelay:  time.Millisecond,
+	}))
+
+	p, err := q.ListPage(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("ListPage: %v", err)
+	}
+	if len(p.Articles) != 1 || len(p.Failures) != 0 {
+		t.Errorf("got %d articles and %v failures, want 1 article", len(p.Articles), p.Failures)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("made %d requests, want 4", got)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	q := newTestSourceFor(server, WithRetry(RetryPolicy{
+		Attempts:  3,
+		BaseDelay: time.Millisecond,
+		MaxDelay:  time.Millisecond,
+	}))
+
+	if _, err := q.ListPage(context.Background(), 0); err == nil {
+		t.Error("ListPage of an unavailable site succeeded")
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("made %d requests, want 3", got)
+	}
+}
+
+func TestRetryTimeout(t *testing.T) {
+	var requests atomic.Int32
+	files := http.FileServer(http.Dir("testdata/site"))
+	// The first request of every page hangs until the client gives up on it
+	var mu sync.Mutex
+	hung := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		mu.Lock()
+		first := !hung[r.URL.Path]
+		hung[r.URL.Path] = true
+		mu.Unlock()
+		if first {
+			<-r.Context().Done()
+			return
+		}
+		files.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	q := newTestSourceFor(server, WithLimits(Limits{Parallelism: 1}), WithRetry(RetryPolicy{
+		Attempts:  2,
+		BaseDelay: time.Millisecond,
+		MaxDelay:  time.Millisecond,
+		Timeout:   100 * time.Millisecond,
+	}))
+
+	p, err := q.ListPage(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("ListPage: %v", err)
+	}
+	if len(p.Articles) != 1 || len(p.Failures) != 0 {
+		t.Errorf("got %d articles and %v failures, want 1 article", len(p.Articles), p.Failures)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("made %d requests, want 4", got)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	var requests atomic.Int32
+	var down atomic.Bool
+	down.Store(true)
+	files := http.FileServer(http.Dir("testdata/site"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		files.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	q := newTestSourceFor(server, WithBreaker(BreakerPolicy{Failures: 2, Cooldown: 20 * time.Millisecond}))
+
+	for range 2 {
+		if _, err := q.ListPage(context.Background(), 1); err == nil {
+			t.Fatal("ListPage of an unavailable site succeeded")
+		}
+	}
+	if !q.Breaker().Open() {
+		t.Fatal("the breaker is closed after 2 failures in a row")
+	}
+
+	// While it's open the site isn't requested at all
+	if _, err := q.ListPage(context.Background(), 1); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("ListPage = %v, want %v", err, ErrCircuitOpen)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("made %d requests, want 2", got)
+	}
+
+	// After the cooldown a request probes the site, it's back so the breaker closes
+	down.Store(false)
+	time.Sleep(30 * time.Millisecond)
+	p, err := q.ListPage(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("ListPage after the cooldown: %v", err)
+	}
+	if len(p.Articles) != 1 || q.Breaker().Open() {
+		t.Errorf("got %d articles and the breaker open = %v, want 1 article and closed", len(p.Articles), q.Breaker().Open())
+	}
+
+	// A reset closes the breaker without waiting for the cooldown
+	down.Store(true)
+	for range 2 {
+		q.ListPage(context.Background(), 1)
+	}
+	down.Store(false)
+	q.Breaker().Reset()
+	if _, err := q.ListPage(context.Background(), 1); err != nil {
+		t.Errorf("ListPage after a reset: %v", err)
+	}
+}
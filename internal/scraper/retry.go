@@ -0,0 +1,132 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// RetryPolicy is how requests that failed because of a timeout, a network error or
+// a server error are retried, waiting exponentially longer between attempts.
+type RetryPolicy struct {
+	// Attempts is the total of attempts, including the first one
+	Attempts int
+	// BaseDelay is the wait before the first retry, it doubles with every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts
+	MaxDelay time.Duration
+	// Timeout is how long each attempt may take, reading the body included. 0 means no limit
+	Timeout time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:  3,
+	BaseDelay: 500 * time.Millisecond,
+	MaxDelay:  8 * time.Second,
+	Timeout:   10 * time.Second,
+}
+
+// delay returns the wait before the given retry, the first one is 1. Half of it is
+// random, so requests that failed together don't retry together.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.BaseDelay << (retry - 1)
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half)
+}
+
+// retryTransport retries the requests according to the policy, every attempt takes a
+// slot of the budget, if there's one. The last response or error is returned.
+type retryTransport struct {
+	policy RetryPolicy
+	budget *Budget
+	base   http.RoundTripper
+}
+
+func (t retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		res, err := t.attempt(req)
+		if attempt >= t.policy.Attempts || !retryable(req.Context(), res, err) {
+			return res, err
+		}
+
+		wait := t.policy.delay(attempt)
+		if res != nil {
+			if after := retryAfter(res); after > wait {
+				wait = after
+			}
+			// The response is discarded, drain it so the connection can be reused
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		log.Warn("Retrying request", "url", req.URL.String(), "attempt", attempt+1, "wait", wait, "error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// attempt makes the request once, giving up after the policy's timeout. The timeout
+// starts once the budget gives the attempt its turn, so the time queued doesn't count.
+// An attempt that timed out doesn't cancel req, so the next one can still be made.
+func (t retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	release := func() {}
+	if t.budget != nil {
+		if err := t.budget.acquire(req.Context()); err != nil {
+			return nil, err
+		}
+		release = t.budget.release
+	}
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if t.policy.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.policy.Timeout)
+	}
+	res, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		release()
+		return nil, err
+	}
+	// The deadline also bounds reading the body, both are released once the body is closed
+	res.Body = &releasingBody{ReadCloser: res.Body, release: func() {
+		cancel()
+		release()
+	}}
+	return res, nil
+}
+
+func retryable(ctx context.Context, res *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		// Our own cancellation isn't worth retrying, anything else is a network error
+		return !errors.Is(err, context.Canceled)
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// retryAfter returns the wait the server asked for, if it did in seconds.
+func retryAfter(res *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
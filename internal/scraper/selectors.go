@@ -0,0 +1,240 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/gocolly/colly/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// SelectorsVersion is the version of the selectors file this build understands.
+const SelectorsVersion = 1
+
+//go:embed selectors.yaml
+var defaultSelectors []byte
+
+// DefaultSelectors are the selectors built into the binary, from selectors.yaml.
+var DefaultSelectors = mustParseSelectors(defaultSelectors)
+
+// Selectors are the CSS selectors the QPC source finds its way around the site with.
+// They're loaded from a versioned YAML file so a redesign of the site only needs a
+// new file, see selectors.yaml.
+type Selectors struct {
+	Version int              `yaml:"version"`
+	Listing ListingSelectors `yaml:"listing"`
+	Menu    MenuSelectors    `yaml:"menu"`
+	Article ArticleSelectors `yaml:"article"`
+}
+
+type ListingSelectors struct {
+	Article       string `yaml:"article"`
+	LinkAttribute string `yaml:"link_attribute"`
+	Pagination    string `yaml:"pagination"`
+	NextText      string `yaml:"next_text"`
+	PreviousText  string `yaml:"previous_text"`
+}
+
+type MenuSelectors struct {
+	Category string `yaml:"category"`
+}
+
+type ArticleSelectors struct {
+	Container string `yaml:"container"`
+	Title     string `yaml:"title"`
+	Category  string `yaml:"category"`
+	Date      string `yaml:"date"`
+	Body      string `yaml:"body"`
+
+	Lead         string   `yaml:"lead"`
+	Author       string   `yaml:"author"`
+	Tags         string   `yaml:"tags"`
+	Image        string   `yaml:"image"`
+	ImageCaption string   `yaml:"image_caption"`
+	Gallery      string   `yaml:"gallery"`
+	Remove       []string `yaml:"remove"`
+}
+
+// NamedSelector is a selector with the name of its key in the file, like "article.title".
+type NamedSelector struct {
+	Name     string
+	Selector string
+	// Optional selectors may match nothing, not every page has what they select
+	Optional bool
+}
+
+// ListingSelectors returns the selectors of the listing pages, that are matched
+// against the whole page.
+func (s *Selectors) ListingSelectors() []NamedSelector {
+	return []NamedSelector{
+		{Name: "listing.article", Selector: s.Listing.Article},
+		{Name: "listing.pagination", Selector: s.Listing.Pagination, Optional: true},
+	}
+}
+
+// MenuSelectors returns the selectors of the site's menu, that are matched against the home page.
+func (s *Selectors) MenuSelectors() []NamedSelector {
+	return []NamedSelector{
+		{Name: "menu.category", Selector: s.Menu.Category},
+	}
+}
+
+// ArticleSelectors returns the selectors of the article pages. Except the
+// container, which is matched against the whole page, they're matched inside of it.
+func (s *Selectors) ArticleSelectors() []NamedSelector {
+	selectors := []NamedSelector{
+		{Name: "article.container", Selector: s.Article.Container},
+		{Name: "article.title", Selector: s.Article.Title},
+		{Name: "article.category", Selector: s.Article.Category},
+		{Name: "article.date", Selector: s.Article.Date},
+		{Name: "article.body", Selector: s.Article.Body},
+		{Name: "article.lead", Selector: s.Article.Lead, Optional: true},
+		{Name: "article.author", Selector: s.Article.Author, Optional: true},
+		{Name: "article.tags", Selector: s.Article.Tags, Optional: true},
+		{Name: "article.image", Selector: s.Article.Image, Optional: true},
+		{Name: "article.image_caption", Selector: s.Article.ImageCaption, Optional: true},
+		{Name: "article.gallery", Selector: s.Article.Gallery, Optional: true},
+	}
+	for i, selector := range s.Article.Remove {
+		selectors = append(selectors, NamedSelector{
+			Name:     fmt.Sprintf("article.remove[%d]", i),
+			Selector: selector,
+			Optional: true,
+		})
+	}
+	return selectors
+}
+
+// LoadSelectors reads and validates the selectors file at path.
+func LoadSelectors(path string) (*Selectors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParseSelectors(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// ParseSelectors decodes a selectors file. Every required selector must be set and
+// every selector must be valid CSS, unknown keys are an error so typos don't go
+// unnoticed.
+func ParseSelectors(data []byte) (*Selectors, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var s Selectors
+	if err := decoder.Decode(&s); err != nil {
+		return nil, fmt.Errorf("decoding the selectors: %w", err)
+	}
+	if s.Version != SelectorsVersion {
+		return nil, fmt.Errorf("unsupported selectors version %d, want %d", s.Version, SelectorsVersion)
+	}
+
+	var errs []error
+	required := []struct{ name, value string }{
+		{"listing.link_attribute", s.Listing.LinkAttribute},
+		{"listing.next_text", s.Listing.NextText},
+		{"listing.previous_text", s.Listing.PreviousText},
+	}
+	for _, field := range required {
+		if field.value == "" {
+			errs = append(errs, fmt.Errorf("%s is empty", field.name))
+		}
+	}
+	selectors := append(s.ListingSelectors(), s.MenuSelectors()...)
+	for _, selector := range append(selectors, s.ArticleSelectors()...) {
+		if selector.Selector == "" {
+			if !selector.Optional {
+				errs = append(errs, fmt.Errorf("%s is empty", selector.Name))
+			}
+			continue
+		}
+		if _, err := cascadia.ParseGroup(selector.Selector); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid selector %q: %w", selector.Name, selector.Selector, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func mustParseSelectors(data []byte) *Selectors {
+	s, err := ParseSelectors(data)
+	if err != nil {
+		panic("scraper: invalid built-in selectors: " + err.Error())
+	}
+	return s
+}
+
+// SelectorCheck is how many elements a selector matched on a page of the site.
+type SelectorCheck struct {
+	NamedSelector
+	URL     string
+	Matches int
+}
+
+// CheckSelectors matches the selectors against the first page of the listing, its
+// first article and the home page, so a change of the site's markup shows up when the
+// selectors are loaded instead of as empty pages or tabs. The checks without matches
+// point to the selectors that need fixing.
+func (q *QPC) CheckSelectors(ctx context.Context) ([]SelectorCheck, error) {
+	sel := q.Selectors()
+
+	var (
+		checks      []SelectorCheck
+		articleLink string
+	)
+	c := q.newCollector(ctx)
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		for _, selector := range sel.ListingSelectors() {
+			checks = append(checks, SelectorCheck{selector, e.Request.URL.String(), e.DOM.Find(selector.Selector).Length()})
+		}
+		article := e.DOM.Find(sel.Listing.Article).First()
+		if link := article.Closest("["+sel.Listing.LinkAttribute+"]").AttrOr(sel.Listing.LinkAttribute, ""); link != "" {
+			articleLink = e.Request.AbsoluteURL(link)
+		}
+	})
+	if err := c.Visit(q.pageURL(0)); err != nil {
+		return nil, err
+	}
+	c.Wait()
+
+	c = q.newCollector(ctx)
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		for _, selector := range sel.MenuSelectors() {
+			checks = append(checks, SelectorCheck{selector, e.Request.URL.String(), e.DOM.Find(selector.Selector).Length()})
+		}
+	})
+	if err := c.Visit(q.BaseURL()); err != nil {
+		return nil, err
+	}
+	c.Wait()
+	if articleLink == "" {
+		return checks, ctx.Err()
+	}
+
+	c = q.newCollector(ctx)
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		selectors := sel.ArticleSelectors()
+		container := e.DOM.Find(selectors[0].Selector)
+		checks = append(checks, SelectorCheck{selectors[0], e.Request.URL.String(), container.Length()})
+		for _, selector := range selectors[1:] {
+			if selector.Selector != "" {
+				checks = append(checks, SelectorCheck{selector, e.Request.URL.String(), container.Find(selector.Selector).Length()})
+			}
+		}
+	})
+	if err := c.Visit(articleLink); err != nil {
+		return nil, err
+	}
+	c.Wait()
+	return checks, ctx.Err()
+}
@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSelectors(t *testing.T) {
+	valid := string(defaultSelectors)
+
+	tests := []struct {
+		name string
+		data string
+		want string // part of the error, empty if it's valid
+	}{
+		{"built-in", valid, ""},
+		{"other version", strings.Replace(valid, "version: 1", "version: 2", 1), "unsupported selectors version 2"},
+		{"unknown key", valid + "\nfooter: .pie\n", "field footer not found"},
+		{"required selector missing", strings.Replace(valid, "title: .titulo2", "title: ''", 1), "article.title is empty"},
+		{"optional selector missing", strings.Replace(valid, "lead: .bajada", "lead: ''", 1), ""},
+		{"invalid gallery selector", strings.Replace(valid, "gallery: .owl-carousel", "gallery: '.owl-carousel >'", 1), "article.gallery: invalid selector"},
+		{"invalid selector", strings.Replace(valid, "body: .resumen", "body: .resumen[", 1), "article.body: invalid selector"},
+		{"invalid removed selector", valid + "    - '>>'\n", "article.remove[4]: invalid selector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSelectors([]byte(tt.data))
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("ParseSelectors: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("ParseSelectors = %v, want an error with %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSelectors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "selectors.yaml")
+	data := strings.Replace(string(defaultSelectors), "title: .titulo2", "title: .titulo", 1)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	selectors, err := LoadSelectors(path)
+	if err != nil {
+		t.Fatalf("LoadSelectors: %v", err)
+	}
+	q, server := newTestSource(t, WithSelectors(selectors))
+
+	// The title is read with the selector of the file
+	article, err := q.FetchArticle(context.Background(), server.URL+"/nota/1001/robo-en-la-plaza/")
+	if err != nil {
+		t.Fatalf("FetchArticle: %v", err)
+	}
+	if article.Title != "Policiales" {
+		t.Errorf("Title = %q, want the text of .titulo", article.Title)
+	}
+
+	q.SetSelectors(DefaultSelectors)
+	article, err = q.FetchArticle(context.Background(), server.URL+"/nota/1001/robo-en-la-plaza/")
+	if err != nil {
+		t.Fatalf("FetchArticle: %v", err)
+	}
+	if article.Title != "Robo en la plaza" {
+		t.Errorf("Title after SetSelectors = %q, want the text of .titulo2", article.Title)
+	}
+}
+
+func TestCheckSelectors(t *testing.T) {
+	selectors := *DefaultSelectors
+	selectors.Article.Date = ".fecha"
+	selectors.Menu.Category = ".menu-viejo a"
+	q, _ := newTestSource(t, WithSelectors(&selectors))
+
+	checks, err := q.CheckSelectors(context.Background())
+	if err != nil {
+		t.Fatalf("CheckSelectors: %v", err)
+	}
+
+	unmatched := map[string]bool{}
+	for _, check := range checks {
+		if check.Matches == 0 {
+			unmatched[check.Name] = true
+		}
+	}
+	for _, name := range []string{"listing.article", "article.container", "article.title", "article.body", "article.lead"} {
+		if unmatched[name] {
+			t.Errorf("%s matched nothing", name)
+		}
+	}
+	for _, name := range []string{"article.date", "menu.category"} {
+		if !unmatched[name] {
+			t.Errorf("%s matched something, want it reported: %+v", name, checks)
+		}
+	}
+
+	// The menu of the built-in selectors matches the home page
+	q, _ = newTestSource(t)
+	checks, err = q.CheckSelectors(context.Background())
+	if err != nil {
+		t.Fatalf("CheckSelectors: %v", err)
+	}
+	menu := 0
+	for _, check := range checks {
+		if check.Name == "menu.category" {
+			menu = check.Matches
+		}
+	}
+	if menu == 0 {
+		t.Errorf("menu.category matched nothing: %+v", checks)
+	}
+}
@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"qpc-tui/internal/body"
+)
+
+type Article struct {
+	// ID identifies the article on its outlet, it's derived from the link so it never changes
+	ID         string      `json:"id"`
+	Title      string      `json:"title"`
+	Date       time.Time   `json:"date"`
+	Category   string      `json:"category"`
+	CategoryId int         `json:"category_id"`
+	Body       body.Blocks `json:"body"`
+	Link       string      `json:"link"`
+
+	// Lead is the summary under the title, the "bajada"
+	Lead   string   `json:"lead,omitempty"`
+	Author string   `json:"author,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	// ImageURL is the main image of the article, shown above the body
+	ImageURL     string `json:"image_url,omitempty"`
+	ImageCaption string `json:"image_caption,omitempty"`
+	// Images are the images in the body and Videos the links of the videos embedded in it
+	Images []string `json:"images,omitempty"`
+	Videos []string `json:"videos,omitempty"`
+}
+
+// ArticleID returns the stable ID of the article with the given link: the path of the
+// link without the host, like "nota/12345/titulo-de-la-nota".
+func ArticleID(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	if id := strings.Trim(u.Path, "/"); id != "" {
+		return id
+	}
+	return link
+}
+
+// Page is a single page of an outlet's paginated listing.
+type Page struct {
+	Number   int
+	Articles []Article
+	// Links of the articles on the page, only set by ListLinks
+	Links []string
+	// Failures are the articles of the page that couldn't be loaded
+	Failures    []ArticleError
+	CanContinue bool
+	CanGoBack   bool
+	// FetchedAt is when the page was scraped
+	FetchedAt time.Time
+	// Stale pages are older copies, returned while the site is scraped again or is down
+	Stale bool
+}
+
+// Category is a section of the outlet, identified by the numeric id the site uses.
+type Category struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	// URL is the listing of the section's articles
+	URL string `json:"url"`
+}
+
+/*
+	Source is a news outlet the app can read from. Each outlet knows how to scrape
+	its own listing and article pages, so adding a new one doesn't require touching
+	the app package.
+*/
+type Source interface {
+	// Name returns a human readable name for the outlet.
+	Name() string
+	// ListPage scrapes the given page of the outlet's listing, giving up on every
+	// outstanding request once ctx is done.
+	ListPage(ctx context.Context, page int) (*Page, error)
+	// ListCategoryPage is like ListPage but scrapes the listing of a single section,
+	// which is paginated on its own.
+	ListCategoryPage(ctx context.Context, category Category, page int) (*Page, error)
+	// ListLinks is like ListPage but only collects the article links, without scraping the articles.
+	ListLinks(ctx context.Context, page int) (*Page, error)
+	// FetchArticle scrapes a single article from its link.
+	FetchArticle(ctx context.Context, link string) (*Article, error)
+	// Categories returns the sections the outlet publishes under, in the order the outlet shows them.
+	Categories(ctx context.Context) ([]Category, error)
+}
+
+// DefaultSource is the source used by ScrapePage.
+var DefaultSource Source = NewQPC()
+
+func ScrapePage(page int) (*Page, error) {
+	return ScrapePageContext(context.Background(), page)
+}
+
+// ScrapePageContext is like ScrapePage but aborts the outstanding visits when ctx is cancelled.
+func ScrapePageContext(ctx context.Context, page int) (*Page, error) {
+	return DefaultSource.ListPage(ctx, page)
+}
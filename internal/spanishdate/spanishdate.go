@@ -0,0 +1,172 @@
+/*
+	Package spanishdate parses the dates the site writes in Spanish, like
+	"Miércoles, 15 de Enero de 2024. 10:30 Hs". Words are matched without accents nor
+	case, months and weekdays may be abbreviated and the time, with or without "Hs",
+	is optional.
+*/
+
+package spanishdate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Location is the time zone of the dates, the site is from Chacabuco, Buenos Aires.
+var Location = loadLocation()
+
+func loadLocation() *time.Location {
+	loc, err := time.LoadLocation("America/Argentina/Buenos_Aires")
+	if err != nil {
+		// Argentina has been UTC-3 without daylight saving time since 2009
+		return time.FixedZone("ART", -3*60*60)
+	}
+	return loc
+}
+
+var months = map[string]time.Month{
+	"enero":      time.January,
+	"ene":        time.January,
+	"febrero":    time.February,
+	"feb":        time.February,
+	"marzo":      time.March,
+	"mar":        time.March,
+	"abril":      time.April,
+	"abr":        time.April,
+	"mayo":       time.May,
+	"may":        time.May,
+	"junio":      time.June,
+	"jun":        time.June,
+	"julio":      time.July,
+	"jul":        time.July,
+	"agosto":     time.August,
+	"ago":        time.August,
+	"septiembre": time.September,
+	"setiembre":  time.September,
+	"sep":        time.September,
+	"sept":       time.September,
+	"set":        time.September,
+	"octubre":    time.October,
+	"oct":        time.October,
+	"noviembre":  time.November,
+	"nov":        time.November,
+	"diciembre":  time.December,
+	"dic":        time.December,
+}
+
+var weekdays = map[string]time.Weekday{
+	"lunes":     time.Monday,
+	"lun":       time.Monday,
+	"martes":    time.Tuesday,
+	"mar":       time.Tuesday,
+	"miercoles": time.Wednesday,
+	"mie":       time.Wednesday,
+	"jueves":    time.Thursday,
+	"jue":       time.Thursday,
+	"viernes":   time.Friday,
+	"vie":       time.Friday,
+	"sabado":    time.Saturday,
+	"sab":       time.Saturday,
+	"domingo":   time.Sunday,
+	"dom":       time.Sunday,
+}
+
+// Words that carry no information, like the "de" in "15 de Enero de 2024" or the "Hs" after the time
+var fillers = map[string]bool{
+	"de":    true,
+	"del":   true,
+	"a":     true,
+	"las":   true,
+	"hs":    true,
+	"h":     true,
+	"hrs":   true,
+	"horas": true,
+}
+
+// A token is a time like 10:30 or 10.30, a number or a word
+var tokenRegexp = regexp.MustCompile(`\d{1,2}[:.]\d{2}|\d+|\pL+`)
+
+// Parse parses the date in Location.
+func Parse(s string) (time.Time, error) {
+	var (
+		day, year    int
+		month        time.Month
+		hour, minute int
+		hasTime      bool
+	)
+
+	for _, token := range tokenRegexp.FindAllString(normalize(s), -1) {
+		switch {
+		case strings.ContainsAny(token, ":."):
+			if hasTime {
+				return time.Time{}, fmt.Errorf("parsing date %q: more than one time", s)
+			}
+			hour, _ = strconv.Atoi(token[:len(token)-3])
+			minute, _ = strconv.Atoi(token[len(token)-2:])
+			if hour > 23 || minute > 59 {
+				return time.Time{}, fmt.Errorf("parsing date %q: invalid time %s", s, token)
+			}
+			hasTime = true
+
+		case isDigits(token):
+			n, _ := strconv.Atoi(token)
+			// The day comes before the month and the year after it
+			if month == 0 && day == 0 {
+				day = n
+			} else if month != 0 && year == 0 {
+				year = n
+				if len(token) == 2 {
+					year += 2000
+				}
+			} else {
+				return time.Time{}, fmt.Errorf("parsing date %q: unexpected number %s", s, token)
+			}
+
+		case fillers[token]:
+
+		// Before the day there can only be the weekday, it's redundant so it's only checked to be a known one.
+		// This also tells apart "mar", which is Tuesday before the day and March after it.
+		case day == 0:
+			if _, ok := weekdays[token]; !ok {
+				return time.Time{}, fmt.Errorf("parsing date %q: unexpected word %q", s, token)
+			}
+
+		default:
+			m, ok := months[token]
+			if !ok || month != 0 {
+				return time.Time{}, fmt.Errorf("parsing date %q: unexpected word %q", s, token)
+			}
+			month = m
+		}
+	}
+
+	if day == 0 || month == 0 || year == 0 {
+		return time.Time{}, fmt.Errorf("parsing date %q: missing day, month or year", s)
+	}
+
+	t := time.Date(year, month, day, hour, minute, 0, 0, Location)
+	// time.Date normalizes dates like February 30, which means the date doesn't exist
+	if t.Day() != day {
+		return time.Time{}, fmt.Errorf("parsing date %q: day %d out of range", s, day)
+	}
+	return t, nil
+}
+
+var accents = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u")
+
+// normalize lowercases the string and removes the accents, so "Miércoles" and "MIERCOLES" match.
+func normalize(s string) string {
+	return accents.Replace(strings.ToLower(s))
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,137 @@
+package spanishdate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMonths(t *testing.T) {
+	tests := []struct {
+		name  string
+		month time.Month
+	}{
+		{"Enero", time.January},
+		{"Febrero", time.February},
+		{"Marzo", time.March},
+		{"Abril", time.April},
+		{"Mayo", time.May},
+		{"Junio", time.June},
+		{"Julio", time.July},
+		{"Agosto", time.August},
+		{"Septiembre", time.September},
+		{"Setiembre", time.September},
+		{"Octubre", time.October},
+		{"Noviembre", time.November},
+		{"Diciembre", time.December},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse("Lunes, 10 de " + tt.name + " de 2024. 08:05 Hs")
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			want := time.Date(2024, tt.month, 10, 8, 5, 0, 0, Location)
+			if !got.Equal(want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseWeekdays(t *testing.T) {
+	// A week of January 2024, starting on Monday the 15th
+	tests := []struct {
+		input string
+		day   int
+	}{
+		{"Lunes, 15 de Enero de 2024. 10:30 Hs", 15},
+		{"Martes, 16 de Enero de 2024. 10:30 Hs", 16},
+		{"Miércoles, 17 de Enero de 2024. 10:30 Hs", 17},
+		{"Miercoles, 17 de Enero de 2024. 10:30 Hs", 17},
+		{"Jueves, 18 de Enero de 2024. 10:30 Hs", 18},
+		{"Viernes, 19 de Enero de 2024. 10:30 Hs", 19},
+		{"Sábado, 20 de Enero de 2024. 10:30 Hs", 20},
+		{"Sabado, 20 de Enero de 2024. 10:30 Hs", 20},
+		{"Domingo, 21 de Enero de 2024. 10:30 Hs", 21},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			want := time.Date(2024, time.January, tt.day, 10, 30, 0, 0, Location)
+			if !got.Equal(want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseVariants(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"lunes, 15 de enero de 2024. 10:30 hs", time.Date(2024, time.January, 15, 10, 30, 0, 0, Location)},
+		{"MIÉRCOLES, 17 DE ENERO DE 2024. 10:30 HS", time.Date(2024, time.January, 17, 10, 30, 0, 0, Location)},
+		{"Lunes, 15 de Enero de 2024. 10:30", time.Date(2024, time.January, 15, 10, 30, 0, 0, Location)},
+		{"Lunes, 15 de Enero de 2024 - 10.30 hs.", time.Date(2024, time.January, 15, 10, 30, 0, 0, Location)},
+		{"Lunes 15 de Enero de 2024", time.Date(2024, time.January, 15, 0, 0, 0, 0, Location)},
+		{"15 de Enero de 2024, 10:30 Hs", time.Date(2024, time.January, 15, 10, 30, 0, 0, Location)},
+		{"Vie, 1 de Mar de 2024. 9:15 Hs", time.Date(2024, time.March, 1, 9, 15, 0, 0, Location)},
+		{"Mar, 5 de Mar de 2024. 23:59 Hs", time.Date(2024, time.March, 5, 23, 59, 0, 0, Location)},
+		{"Sáb 7 sept 24 21:00", time.Date(2024, time.September, 7, 21, 0, 0, 0, Location)},
+		{"Lunes,  15   de Enero  de 2024.   10:30  Hs", time.Date(2024, time.January, 15, 10, 30, 0, 0, Location)},
+		{"Jueves, 29 de Febrero de 2024. 00:00 Hs", time.Date(2024, time.February, 29, 0, 0, 0, 0, Location)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	got, err := Parse("Lunes, 15 de Enero de 2024. 10:30 Hs")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got.Location() != Location {
+		t.Errorf("location = %v, want %v", got.Location(), Location)
+	}
+	// Argentina is UTC-3
+	if want := time.Date(2024, time.January, 15, 13, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got.UTC(), want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"Lunes",
+		"Lunes, 15 de 2024. 10:30 Hs",
+		"Lunes, 15 de Enero. 10:30 Hs",
+		"Lunes, de Enero de 2024. 10:30 Hs",
+		"Lunes, 15 de Brumario de 2024. 10:30 Hs",
+		"Feriado, 15 de Enero de 2024. 10:30 Hs",
+		"Lunes, 15 de Enero de Marzo de 2024. 10:30 Hs",
+		"Lunes, 30 de Febrero de 2024. 10:30 Hs",
+		"Lunes, 15 de Enero de 2024. 25:30 Hs",
+		"Lunes, 15 de Enero de 2024. 10:30 Hs 11:00",
+		"Lunes, 15 de Enero de 2024 2025. 10:30 Hs",
+	}
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if got, err := Parse(input); err == nil {
+				t.Errorf("Parse succeeded with %v, want an error", got)
+			}
+		})
+	}
+}
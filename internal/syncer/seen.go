@@ -0,0 +1,83 @@
+package syncer
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Seen is the set of article links the syncer already knows, persisted to a file
+// with one link per line so it survives restarts.
+type Seen struct {
+	mu    sync.RWMutex
+	file  *os.File
+	links map[string]bool
+}
+
+// OpenSeen loads the set stored at path, creating it if it doesn't exist.
+func OpenSeen(path string) (*Seen, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Seen{file: file, links: make(map[string]bool)}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if link := strings.TrimSpace(scanner.Text()); link != "" {
+			s.links[link] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Seen) Has(link string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.links[link]
+}
+
+func (s *Seen) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.links)
+}
+
+// Add marks the links as seen, the ones already known are skipped.
+func (s *Seen) Add(links ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+	for _, link := range links {
+		if !s.links[link] {
+			b.WriteString(link + "\n")
+		}
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+	// A newline first, in case the last write was interrupted halfway through a line
+	if _, err := s.file.WriteString("\n" + b.String()); err != nil {
+		return err
+	}
+	for _, link := range links {
+		s.links[link] = true
+	}
+	return nil
+}
+
+func (s *Seen) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
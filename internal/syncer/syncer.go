@@ -0,0 +1,105 @@
+/*
+	Package syncer keeps up with the articles a source publishes. Instead of scraping
+	everything again it walks the listing from the front page, only collecting links,
+	and stops at the first page with a link it already saw, where it caught up with the
+	last sync. In the common case that's one request plus one per new article.
+*/
+
+package syncer
+
+import (
+	"context"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"qpc-tui/internal/scraper"
+)
+
+// maxPages bounds how far back a sync goes, in case the site reorders its listing.
+const maxPages = 10
+
+type Syncer struct {
+	source scraper.Source
+	seen   *Seen
+}
+
+func New(source scraper.Source, seen *Seen) *Syncer {
+	return &Syncer{source: source, seen: seen}
+}
+
+// Sync fetches the articles published since the last sync, in the order they appear
+// in the listing. Articles that fail to load aren't marked as seen, so the next sync
+// retries them, but they don't make it go further back: only the links already seen
+// tell where the last sync ended.
+func (s *Syncer) Sync(ctx context.Context) ([]scraper.Article, error) {
+	// There's nothing to compare against on the first sync, the front page is the baseline
+	if s.seen.Len() == 0 {
+		p, err := s.source.ListLinks(ctx, 0)
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.seen.Add(p.Links...)
+	}
+
+	var articles []scraper.Article
+	for page := 0; page < maxPages; page++ {
+		p, err := s.source.ListLinks(ctx, page)
+		if err != nil {
+			return articles, err
+		}
+
+		var newLinks []string
+		caughtUp := len(p.Links) == 0
+		for _, link := range p.Links {
+			if s.seen.Has(link) {
+				caughtUp = true
+			} else {
+				newLinks = append(newLinks, link)
+			}
+		}
+
+		for _, link := range newLinks {
+			article, err := s.source.FetchArticle(ctx, link)
+			if err != nil {
+				log.Error("Could not sync article", "link", link, "error", err)
+				continue
+			}
+			articles = append(articles, *article)
+			if err := s.seen.Add(link); err != nil {
+				return articles, err
+			}
+		}
+
+		if caughtUp || !p.CanContinue {
+			break
+		}
+	}
+	return articles, nil
+}
+
+// Run syncs every interval until ctx is done, calling onNew with the articles found
+// when there are any.
+func (s *Syncer) Run(ctx context.Context, interval time.Duration, onNew func([]scraper.Article)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		articles, err := s.Sync(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error("Sync failed", "error", err)
+		}
+		if len(articles) > 0 {
+			log.Info("Synced new articles", "count", len(articles))
+			if onNew != nil {
+				onNew(articles)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"qpc-tui/internal/scraper"
+)
+
+// fakeSource lists pageSize links per page, newest first, out of the links published so far.
+type fakeSource struct {
+	scraper.Source
+	published []string
+	broken    map[string]bool
+	listed    []int
+	fetched   []string
+}
+
+const pageSize = 3
+
+func (s *fakeSource) publish(links ...string) {
+	s.published = append(links, s.published...)
+}
+
+func (s *fakeSource) ListLinks(ctx context.Context, page int) (*scraper.Page, error) {
+	s.listed = append(s.listed, page)
+	start := min(page*pageSize, len(s.published))
+	end := min(start+pageSize, len(s.published))
+	return &scraper.Page{
+		Number:      page,
+		Links:       s.published[start:end],
+		CanContinue: end < len(s.published),
+	}, nil
+}
+
+func (s *fakeSource) FetchArticle(ctx context.Context, link string) (*scraper.Article, error) {
+	s.fetched = append(s.fetched, link)
+	if s.broken[link] {
+		return nil, errors.New("not an article")
+	}
+	return &scraper.Article{Link: link}, nil
+}
+
+func TestSync(t *testing.T) {
+	source := &fakeSource{broken: map[string]bool{}}
+	for i := range 30 {
+		source.publish(fmt.Sprintf("/nota/%d", i))
+	}
+	seen, err := OpenSeen(filepath.Join(t.TempDir(), "seen.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer seen.Close()
+	s := New(source, seen)
+	ctx := context.Background()
+
+	// The first sync only takes the front page as the baseline
+	if articles, err := s.Sync(ctx); err != nil || len(articles) != 0 || len(source.fetched) != 0 {
+		t.Fatalf("first Sync = %d articles, %v, fetched %v, want nothing", len(articles), err, source.fetched)
+	}
+
+	// A new article pushes a seen link to page 1, page 0 still has seen links
+	source.listed, source.fetched = nil, nil
+	source.publish("/nota/nueva")
+	articles, err := s.Sync(ctx)
+	if err != nil || len(articles) != 1 || articles[0].Link != "/nota/nueva" {
+		t.Fatalf("Sync = %+v, %v, want the new article", articles, err)
+	}
+	if len(source.listed) != 1 || len(source.fetched) != 1 {
+		t.Errorf("listed pages %v and fetched %v, want only page 0 and the new article", source.listed, source.fetched)
+	}
+
+	// A whole page of new articles goes on to the next page, up to the seen links
+	source.listed, source.fetched = nil, nil
+	source.publish("/nota/a", "/nota/b", "/nota/c", "/nota/d")
+	if articles, err := s.Sync(ctx); err != nil || len(articles) != 4 {
+		t.Errorf("Sync = %d articles, %v, want 4", len(articles), err)
+	}
+	if len(source.listed) != 2 {
+		t.Errorf("listed pages %v, want 0 and 1", source.listed)
+	}
+
+	// A link that never loads is retried, but doesn't make every sync walk further back
+	source.broken["/nota/rota"] = true
+	source.publish("/nota/rota")
+	for range 2 {
+		source.listed, source.fetched = nil, nil
+		if articles, err := s.Sync(ctx); err != nil || len(articles) != 0 {
+			t.Errorf("Sync = %d articles, %v, want none", len(articles), err)
+		}
+		if len(source.listed) != 1 || len(source.fetched) != 1 || source.fetched[0] != "/nota/rota" {
+			t.Errorf("listed pages %v and fetched %v, want only page 0 and the broken link", source.listed, source.fetched)
+		}
+	}
+}
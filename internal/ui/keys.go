@@ -19,11 +19,18 @@ type KeyMap struct {
 	Help  KeyBinding
 	Quit  KeyBinding
 	Tab   KeyBinding
+	// Refresh is only enabled when there are new entries to load
+	Refresh KeyBinding
+	// Retry is only enabled when some articles of the page couldn't be loaded, or
+	// when the error shown above the list can be retried
+	Retry KeyBinding
+	// Dismiss hides the error shown above the list
+	Dismiss KeyBinding
 }
 
 func (k KeyMap) ShortHelp() []key.Binding {
 	bindings := []key.Binding{}
-	for _, kb := range []KeyBinding{k.Left, k.Right, k.Enter, k.Tab, k.Help, k.Quit} {
+	for _, kb := range []KeyBinding{k.Left, k.Right, k.Enter, k.Tab, k.Refresh, k.Retry, k.Dismiss, k.Help, k.Quit} {
 		if kb.Enabled {
 			bindings = append(bindings, kb.Binding)
 		}
@@ -36,7 +43,7 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 		k.enabledBindings(k.Left, k.Right),
 		k.enabledBindings(k.Up, k.Down),
 		k.enabledBindings(k.Next, k.Prev),
-		k.enabledBindings(k.Enter, k.Tab),
+		k.enabledBindings(k.Enter, k.Tab, k.Refresh, k.Retry, k.Dismiss),
 		k.enabledBindings(k.Help, k.Quit),
 	}
 }
@@ -81,6 +88,12 @@ func (k *KeyMap) setKeyState(keyName string, enabled bool) {
 		k.Quit.Enabled = enabled
 	case "Tab":
 		k.Tab.Enabled = enabled
+	case "Refresh":
+		k.Refresh.Enabled = enabled
+	case "Retry":
+		k.Retry.Enabled = enabled
+	case "Dismiss":
+		k.Dismiss.Enabled = enabled
 	}
 }
 
@@ -155,4 +168,25 @@ var Keys = KeyMap{
 		),
 		Enabled: true,
 	},
+	Refresh: KeyBinding{
+		Binding: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "actualizar"),
+		),
+		Enabled: false,
+	},
+	Retry: KeyBinding{
+		Binding: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "reintentar"),
+		),
+		Enabled: false,
+	},
+	Dismiss: KeyBinding{
+		Binding: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "descartar error"),
+		),
+		Enabled: false,
+	},
 }